@@ -63,7 +63,7 @@ func main() {
 		fmt.Fprintf(ctx.W, "hello,world")
 	})
 	group.Get("/get/:id", func(ctx *zorm.Context) {
-		fmt.Fprintf(ctx.W, "get user info")
+		fmt.Fprintf(ctx.W, "get user info: %s", ctx.Param("id"))
 	})
 	group.Get("/g/*/get", func(ctx *zorm.Context) {
 		fmt.Fprintf(ctx.W, "/get/*/get")
@@ -316,8 +316,12 @@ func main() {
 		}
 		ctx.JSON(http.StatusOK, jwtResponse)
 	})
+	engine.OnShutdown(pool.Release)
+	engine.ShutdownOnSignal(10 * time.Second)
 	//engine.Run()
-	engine.RunTLS(":8118", "key/server.pem", "key/server.key")
+	if err := engine.RunTLS(":8118", "key/server.pem", "key/server.key"); err != nil {
+		log.Fatal(err)
+	}
 }
 
 type BlogResponse struct {
@@ -1,18 +1,38 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"github.com/caixr9527/goodscenter/api"
 	"github.com/caixr9527/goodscenter/model"
 	"github.com/caixr9527/zorm"
+	"github.com/caixr9527/zorm/binding"
 	"github.com/caixr9527/zorm/breaker"
+	"github.com/caixr9527/zorm/metrics"
+	"github.com/caixr9527/zorm/tracing"
+	"log"
 	"net/http"
+	"time"
 )
 
 func main() {
 	engine := zorm.Default()
+	// mall-gateway runs on the same host and forwards the client IP
+	if err := engine.SetTrustedProxies([]string{"127.0.0.1", "::1"}); err != nil {
+		log.Fatal(err)
+	}
+	tracer := tracing.NewTracer("goodscenter", tracing.WithExporter(tracing.NewStdoutExporter(nil)))
+	tracing.SetDefaultTracer(tracer)
+	engine.OnShutdown(func() {
+		tracer.Shutdown(context.Background())
+	})
+	engine.ShutdownOnSignal(10 * time.Second)
+	// added after Default, so these run outside its Recovery
+	engine.Use(zorm.Metrics, zorm.Tracing)
 	//engine.Use(zorm.Limiter(1, 1))
+	engine.Metrics("/metrics")
 	group := engine.Group("goods")
-	settings := breaker.Settings{}
+	settings := breaker.Settings{Name: "goods"}
 	settings.Fallback = func(err error) (any, error) {
 		goods := &model.Goods{
 			Id:   1000,
@@ -21,6 +41,7 @@ func main() {
 		return goods, err
 	}
 	var cb = breaker.NewCircuitBreaker(settings)
+	metrics.MustRegister(metrics.NewBreakerCollector(cb))
 	group.Get("/find", func(ctx *zorm.Context) {
 		result, err := cb.Execute(func() (any, error) {
 			query := ctx.GetQuery("id")
@@ -50,6 +71,25 @@ func main() {
 		})
 
 	})
+	group.Get("/get", func(ctx *zorm.Context) {
+		goods := &model.Goods{
+			Id:   1000,
+			Name: "9002",
+		}
+		ctx.Negotiate(http.StatusOK, zorm.Negotiate{
+			Offered: []string{binding.MIMEJSON, binding.MIMEPROTOBUF, binding.MIMEYAML},
+			Data: &model.Result{
+				Code: 200,
+				Msg:  "success",
+				Data: goods,
+			},
+			ProtoBufData: &api.GoodsResponse{
+				Code: 200,
+				Msg:  "success",
+				Data: &api.Goods{Id: goods.Id, Name: goods.Name},
+			},
+		})
+	})
 	//listen, _ := net.Listen("tcp", ":9111")
 	//server := grpc.NewServer()
 	//server, _ := rpc.NewGrpcServer(":9111")
@@ -64,6 +104,8 @@ func main() {
 	//gob.Register(&model.Goods{})
 	//tcpServer.Register("goods", &service.GoodsRpcService{})
 	//tcpServer.Run()
-	engine.Run(":9002")
+	if err := engine.Run(":9002"); err != nil {
+		log.Fatal(err)
+	}
 
 }
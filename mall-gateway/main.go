@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"github.com/caixr9527/zorm"
 	"github.com/caixr9527/zorm/gateway"
+	"github.com/caixr9527/zorm/tracing"
+	"log"
 	"net/http"
+	"time"
 )
 
 func main() {
 	engine := zorm.Default()
 	engine.OpenGateway = true
+	tracer := tracing.NewTracer("mall-gateway", tracing.WithExporter(tracing.NewStdoutExporter(nil)))
+	tracing.SetDefaultTracer(tracer)
+	engine.OnShutdown(func() {
+		tracer.Shutdown(context.Background())
+	})
+	engine.ShutdownOnSignal(10 * time.Second)
 	var configs []gateway.GWConfig
 	configs = append(configs, gateway.GWConfig{
 		Name: "order",
@@ -27,6 +37,10 @@ func main() {
 			req.Header.Set("my", "caixiaorong")
 		},
 	})
-	engine.SetGatewayConfig(configs)
-	engine.Run(":81")
+	if err := engine.SetGatewayConfig(configs); err != nil {
+		log.Fatal(err)
+	}
+	if err := engine.Run(":81"); err != nil {
+		log.Fatal(err)
+	}
 }
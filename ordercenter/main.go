@@ -8,16 +8,45 @@ import (
 	"github.com/caixr9527/ordercenter/api"
 	"github.com/caixr9527/ordercenter/service"
 	"github.com/caixr9527/zorm"
+	"github.com/caixr9527/zorm/render"
 	"github.com/caixr9527/zorm/rpc"
+	"github.com/caixr9527/zorm/tracing"
 	"log"
+	"net"
 	"net/http"
+	"time"
 )
 
 func main() {
 	engine := zorm.Default()
+	// mall-gateway runs on the same host and forwards the client IP
+	if err := engine.SetTrustedProxies([]string{"127.0.0.1", "::1"}); err != nil {
+		log.Fatal(err)
+	}
+	tracer := tracing.NewTracer("ordercenter", tracing.WithExporter(tracing.NewStdoutExporter(nil)))
+	tracing.SetDefaultTracer(tracer)
+	engine.OnShutdown(func() {
+		tracer.Shutdown(context.Background())
+	})
+	engine.ShutdownOnSignal(10 * time.Second)
+	engine.Use(zorm.Tracing)
 	client := rpc.NewHttpClient()
 	client.RegisterHttpService("goods", &service.GoodsService{})
 	group := engine.Group("order")
+	broker := zorm.NewSSEBroker(16)
+	// browsers follow order status with new EventSource("/order/events")
+	group.Get("/events", broker.Serve)
+	// status changes are pushed by the services on this host
+	group.Post("/status", func(ctx *zorm.Context) {
+		status := &orderStatus{}
+		ctx.IsValidate = true
+		ctx.DisallowUnknownFields = true
+		if err := ctx.BindJson(status); err != nil {
+			return
+		}
+		broker.Publish(render.SSEvent{Event: "status", Data: status})
+		ctx.JSON(http.StatusOK, status)
+	}, localOnly)
 	group.Get("/find", func(ctx *zorm.Context) {
 		params := make(map[string]any)
 		params["id"] = ctx.GetQuery("id")
@@ -27,14 +56,14 @@ func main() {
 		//	panic(err)
 		//}
 
-		body, err := client.Do("goods", "Find").(*service.GoodsService).Find(params)
+		body, err := client.DoWithContext(ctx, "goods", "Find").(*service.GoodsService).Find(params)
 		if err != nil {
 			panic(err)
 		}
 		v := &model.Result{}
 		json.Unmarshal(body, v)
 		ctx.JSON(http.StatusOK, v)
-	})
+	}, zorm.Timeout(3*time.Second))
 	group.Get("/findGrpc", func(ctx *zorm.Context) {
 		config := rpc.DefaultGrpcClientConfig()
 		config.Address = "localhost:9111"
@@ -44,7 +73,7 @@ func main() {
 		}
 		defer client.Conn.Close()
 		apiClient := api.NewGoodsApiClient(client.Conn)
-		goodsResponse, err := apiClient.Find(context.Background(), &api.GoodsRequest{})
+		goodsResponse, err := apiClient.Find(ctx, &api.GoodsRequest{})
 		ctx.JSON(http.StatusOK, goodsResponse)
 	})
 
@@ -56,9 +85,28 @@ func main() {
 		proxy := rpc.NewTcpClientProxy(option)
 		params := make([]any, 1)
 		params[0] = int64(1)
-		result, err := proxy.Call(context.Background(), "goods", "Find", params)
+		result, err := proxy.Call(ctx, "goods", "Find", params)
 		log.Println(err)
 		ctx.JSON(http.StatusOK, result)
 	})
-	engine.Run(":9003")
+	if err := engine.Run(":9003"); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type orderStatus struct {
+	Id     string `json:"id" required:"true"`
+	Status string `json:"status" required:"true"`
+}
+
+// localOnly rejects requests whose client is not on this host, clients
+// behind mall-gateway are resolved through X-Forwarded-For.
+func localOnly(next zorm.HandlerFunc) zorm.HandlerFunc {
+	return func(ctx *zorm.Context) {
+		if ip := net.ParseIP(ctx.ClientIP()); ip == nil || !ip.IsLoopback() {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		next(ctx)
+	}
 }
@@ -36,9 +36,10 @@ func (a *Accounts) BasicAuth(next HandlerFunc) HandlerFunc {
 func (a *Accounts) unAuthHandler(ctx *Context) {
 	if a.UnAuthHandler != nil {
 		a.UnAuthHandler(ctx)
+		ctx.Abort()
 	} else {
 		ctx.W.Header().Set("WWW-Authenticate", a.Realm)
-		ctx.W.WriteHeader(http.StatusUnauthorized)
+		ctx.AbortWithStatus(http.StatusUnauthorized)
 	}
 }
 
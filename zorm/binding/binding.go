@@ -2,12 +2,56 @@ package binding
 
 import "net/http"
 
+const (
+	MIMEJSON              = "application/json"
+	MIMEHTML              = "text/html"
+	MIMEXML               = "application/xml"
+	MIMEXML2              = "text/xml"
+	MIMEPlain             = "text/plain"
+	MIMEPOSTForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartPOSTForm = "multipart/form-data"
+	MIMEYAML              = "application/yaml"
+	MIMETOML              = "application/toml"
+	MIMEPROTOBUF          = "application/x-protobuf"
+	MIMEMSGPACK           = "application/msgpack"
+)
+
 type Binding interface {
 	Name() string
 	Bind(*http.Request, any) error
 }
 
+// BindingUri binds the params captured by the route, e.g. ":id".
+type BindingUri interface {
+	Name() string
+	BindUri(map[string][]string, any) error
+}
+
 var (
-	JSON = jsonBinding{}
-	XML  = xmlBinding{}
+	JSON          = jsonBinding{}
+	XML           = xmlBinding{}
+	Form          = formBinding{}
+	Query         = queryBinding{}
+	FormPost      = formPostBinding{}
+	FormMultipart = formMultipartBinding{}
+	Header        = headerBinding{}
+	Uri           = uriBinding{}
 )
+
+// Default returns the binding for a request method and a Content-Type
+// without parameters, requests without a body are bound from the form.
+func Default(method, contentType string) Binding {
+	if method == http.MethodGet {
+		return Form
+	}
+	switch contentType {
+	case MIMEJSON:
+		return JSON
+	case MIMEXML, MIMEXML2:
+		return XML
+	case MIMEMultipartPOSTForm:
+		return FormMultipart
+	default:
+		return Form
+	}
+}
@@ -0,0 +1,87 @@
+package binding
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"strings"
+)
+
+// FieldError is one failed rule, Field is the path of the value in the
+// request body, e.g. "items[2].age".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+	err     validator.FieldError
+}
+
+// ValidationErrors lists every field that failed validation, messages are
+// translated to DefaultLocale.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Translate returns a copy of e with the messages in locale, falling back
+// to DefaultLocale when no translator is registered for it.
+func (e ValidationErrors) Translate(locale string) ValidationErrors {
+	t := translator(locale)
+	translated := make(ValidationErrors, len(e))
+	for i, fe := range e {
+		translated[i] = fe
+		if fe.err != nil {
+			translated[i].Message = t.Translate(fe.err)
+		}
+	}
+	return translated
+}
+
+func (e ValidationErrors) withPrefix(prefix string) ValidationErrors {
+	for i := range e {
+		if strings.HasPrefix(e[i].Field, "[") {
+			e[i].Field = prefix + e[i].Field
+		} else {
+			e[i].Field = prefix + "." + e[i].Field
+		}
+	}
+	return e
+}
+
+// newValidationErrors converts the errors of validator, other errors are
+// returned unchanged.
+func newValidationErrors(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	t := translator(DefaultLocale)
+	errs := make(ValidationErrors, len(verrs))
+	for i, fe := range verrs {
+		rule := fe.Tag()
+		if rule == requiredTag {
+			rule = "required"
+		}
+		errs[i] = FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Rule:    rule,
+			Param:   fe.Param(),
+			Message: t.Translate(fe),
+			err:     fe,
+		}
+	}
+	return errs
+}
+
+// fieldPath drops the struct name validator puts in front of the namespace.
+func fieldPath(namespace string) string {
+	if i := strings.IndexAny(namespace, ".["); i >= 0 && namespace[i] == '.' {
+		return namespace[i+1:]
+	}
+	return namespace
+}
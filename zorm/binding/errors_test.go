@@ -0,0 +1,58 @@
+package binding
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"testing"
+)
+
+type testItem struct {
+	Age int `json:"age" validate:"gte=18"`
+}
+
+type testOrder struct {
+	Name  string     `json:"name" validate:"required"`
+	Items []testItem `json:"items" validate:"dive"`
+}
+
+func TestValidationErrors(t *testing.T) {
+	err := validate(&testOrder{Items: []testItem{{Age: 20}, {Age: 20}, {Age: 3}}})
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("got %T %v", err, err)
+	}
+	want := ValidationErrors{
+		{Field: "name", Rule: "required", Message: "name is a required field"},
+		{Field: "items[2].age", Rule: "gte", Param: "18", Message: "age must be 18 or greater"},
+	}
+	for i := range verrs {
+		verrs[i].err = nil
+	}
+	if !reflect.DeepEqual(verrs, want) {
+		t.Errorf("got %#v", verrs)
+	}
+
+	err = validate([]testItem{{Age: 20}, {Age: 1}})
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Field != "[1].age" {
+		t.Errorf("slice: got %v", err)
+	}
+	zh := verrs.Translate("zh-CN")
+	if zh[0].Message != "age必须大于或等于18" {
+		t.Errorf("zh: got %q", zh[0].Message)
+	}
+
+	RegisterTranslator("test", TranslatorFunc(func(fe validator.FieldError) string {
+		return fe.Tag()
+	}))
+	if msg := verrs.Translate("test")[0].Message; msg != "gte" {
+		t.Errorf("custom translator: got %q", msg)
+	}
+}
+
+func TestSliceValidationError(t *testing.T) {
+	err := SliceValidationError{nil, errors.New("a"), nil, errors.New("b")}
+	if got := err.Error(); got != "[1]:a\n[3]:b" {
+		t.Errorf("got %q", got)
+	}
+}
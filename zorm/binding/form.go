@@ -0,0 +1,42 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+)
+
+const defaultMemory = 32 << 20
+
+type formBinding struct{}
+
+type formPostBinding struct{}
+
+func (formBinding) Name() string {
+	return "form"
+}
+
+func (formBinding) Bind(r *http.Request, obj any) error {
+	if err := r.ParseMultipartForm(defaultMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	sent, err := mapForm(obj, r.Form)
+	if err != nil {
+		return err
+	}
+	return validateSent(obj, sent)
+}
+
+func (formPostBinding) Name() string {
+	return "form-urlencoded"
+}
+
+func (formPostBinding) Bind(r *http.Request, obj any) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	sent, err := mapForm(obj, r.PostForm)
+	if err != nil {
+		return err
+	}
+	return validateSent(obj, sent)
+}
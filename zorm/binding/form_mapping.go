@@ -0,0 +1,282 @@
+package binding
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errUnknownType = errors.New("unknown type")
+
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// source looks up the raw values of a key, e.g. a form or the headers.
+type source interface {
+	values(key string) ([]string, bool)
+}
+
+type formSource map[string][]string
+
+func (s formSource) values(key string) ([]string, bool) {
+	values, ok := s[key]
+	return values, ok
+}
+
+func mapForm(obj any, form map[string][]string) (sentFields, error) {
+	return mapping(obj, formSource(form), "form")
+}
+
+// mapping fills the fields of the struct obj points to from src. The field
+// name is taken from tag and falls back to the field name, "-" skips the
+// field and ",default=value" is used when the key is missing. Struct fields
+// that are not time.Time or a TextUnmarshaler are mapped recursively. The
+// fields set are returned for the required:"true" check.
+func mapping(obj any, src source, tag string) (sentFields, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, errors.New("binding: obj must be a non-nil pointer")
+	}
+	sent := sentFields{}
+	_, err := mapValue(v.Elem(), reflect.StructField{Anonymous: true}, src, tag, sent)
+	return sent, err
+}
+
+func mapValue(v reflect.Value, field reflect.StructField, src source, tag string, sent sentFields) (bool, error) {
+	if field.Tag.Get(tag) == "-" {
+		return false, nil
+	}
+	if v.Kind() == reflect.Pointer && !isLeaf(v.Type()) {
+		elem := v
+		if v.IsNil() {
+			elem = reflect.New(v.Type().Elem())
+		}
+		isSet, err := mapValue(elem.Elem(), field, src, tag, sent)
+		if isSet && v.IsNil() {
+			v.Set(elem)
+		}
+		return isSet, err
+	}
+	if v.Kind() == reflect.Struct && !isLeaf(v.Type()) {
+		isSet := false
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() && (!sf.Anonymous || sf.Type.Kind() == reflect.Pointer) {
+				continue
+			}
+			ok, err := mapValue(v.Field(i), sf, src, tag, sent)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				sent.add(v, sf.Name)
+			}
+			isSet = isSet || ok
+		}
+		return isSet, nil
+	}
+	if field.Anonymous {
+		return false, nil
+	}
+	return setField(v, field, src, tag)
+}
+
+// isLeaf reports whether t is set from a single value instead of being
+// walked field by field.
+func isLeaf(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType || t == fileHeaderType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	return t.Kind() != reflect.Struct
+}
+
+func setField(v reflect.Value, field reflect.StructField, src source, tag string) (bool, error) {
+	name, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "" {
+		name = field.Name
+	}
+	if fs, ok := src.(multipartSource); ok && isFileType(v.Type()) {
+		return fs.setFiles(v, field, name)
+	}
+	values, ok := src.values(name)
+	if !ok {
+		defaultValue, found := tagOption(opts, "default")
+		if !found {
+			return false, nil
+		}
+		values = []string{defaultValue}
+	}
+	if err := setValues(v, field, values); err != nil {
+		return false, fmt.Errorf("binding: field [%s]: %w", name, err)
+	}
+	return true, nil
+}
+
+func tagOption(opts, key string) (string, bool) {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if k, value, ok := strings.Cut(opt, "="); ok && k == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func setValues(v reflect.Value, field reflect.StructField, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	switch {
+	case v.Kind() == reflect.Slice && !isTextUnmarshaler(v) && v.Type().Elem().Kind() != reflect.Uint8:
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), field, value); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case v.Kind() == reflect.Array && !isTextUnmarshaler(v):
+		if len(values) != v.Len() {
+			return fmt.Errorf("%q is not valid value for %s", values, v.Type())
+		}
+		for i, value := range values {
+			if err := setValue(v.Index(i), field, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return setValue(v, field, values[0])
+}
+
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType)
+}
+
+func setValue(v reflect.Value, field reflect.StructField, value string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), field, value)
+	}
+	if v.Type() != timeType && isTextUnmarshaler(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			value = "false"
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		if value == "" {
+			value = "0"
+		}
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			value = "0"
+		}
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			value = "0"
+		}
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return setTime(v, field, value)
+		}
+		return json.Unmarshal([]byte(value), v.Addr().Interface())
+	case reflect.Map, reflect.Slice:
+		return json.Unmarshal([]byte(value), v.Addr().Interface())
+	default:
+		return errUnknownType
+	}
+	return nil
+}
+
+// setTime parses value with the time_format tag, RFC3339 by default, "unix"
+// and "unixnano" read a timestamp. time_utc and time_location pick the
+// location of layouts without a zone.
+func setTime(v reflect.Value, field reflect.StructField, value string) error {
+	if value == "" {
+		v.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+	layout := field.Tag.Get("time_format")
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	switch strings.ToLower(layout) {
+	case "unix", "unixnano":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		t := time.Unix(n, 0)
+		if strings.ToLower(layout) == "unixnano" {
+			t = time.Unix(0, n)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	loc := time.Local
+	if utc, _ := strconv.ParseBool(field.Tag.Get("time_utc")); utc {
+		loc = time.UTC
+	}
+	if name := field.Tag.Get("time_location"); name != "" {
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return err
+		}
+		loc = l
+	}
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
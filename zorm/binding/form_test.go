@@ -0,0 +1,212 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `form:"city"`
+	Zip  *int   `form:"zip"`
+}
+
+type testForm struct {
+	Name    string        `form:"name"`
+	Age     int           `form:"age,default=18"`
+	Tags    []string      `form:"tag"`
+	Score   *float64      `form:"score"`
+	Born    time.Time     `form:"born" time_format:"2006-01-02" time_utc:"true"`
+	Timeout time.Duration `form:"timeout"`
+	IP      net.IP        `form:"ip"`
+	Ignored string        `form:"-"`
+	Address testAddress
+	Extra   *testAddress
+}
+
+func TestMapForm(t *testing.T) {
+	form := url.Values{
+		"name":    {"zorm"},
+		"tag":     {"a", "b"},
+		"score":   {"9.5"},
+		"born":    {"2020-02-01"},
+		"timeout": {"3s"},
+		"ip":      {"10.0.0.1"},
+		"Ignored": {"x"},
+		"city":    {"sz"},
+		"zip":     {"518000"},
+	}
+	var obj testForm
+	if _, err := mapForm(&obj, form); err != nil {
+		t.Fatal(err)
+	}
+	score, zip := 9.5, 518000
+	want := testForm{
+		Name:    "zorm",
+		Age:     18,
+		Tags:    []string{"a", "b"},
+		Score:   &score,
+		Born:    time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+		Timeout: 3 * time.Second,
+		IP:      net.ParseIP("10.0.0.1"),
+		Address: testAddress{City: "sz", Zip: &zip},
+		Extra:   &testAddress{City: "sz", Zip: &zip},
+	}
+	if !reflect.DeepEqual(obj, want) {
+		t.Errorf("mapForm = %+v, want %+v", obj, want)
+	}
+
+	if _, err := mapForm(&obj, url.Values{"age": {"old"}}); err == nil || !strings.Contains(err.Error(), "age") {
+		t.Errorf("invalid int error = %v", err)
+	}
+}
+
+func TestBindings(t *testing.T) {
+	type header struct {
+		Token string `header:"x-token"`
+		Limit uint8  `header:"X-Limit"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Token", "t")
+	r.Header.Set("X-Limit", "5")
+	var h header
+	if err := Header.Bind(r, &h); err != nil || h != (header{Token: "t", Limit: 5}) {
+		t.Errorf("Header.Bind = %+v, %v", h, err)
+	}
+
+	type query struct {
+		Page int    `form:"page" validate:"gte=1"`
+		Sort string `form:"sort"`
+	}
+	var q query
+	r = httptest.NewRequest(http.MethodGet, "/?page=2&sort=id", nil)
+	if err := Query.Bind(r, &q); err != nil || q != (query{Page: 2, Sort: "id"}) {
+		t.Errorf("Query.Bind = %+v, %v", q, err)
+	}
+	r = httptest.NewRequest(http.MethodGet, "/?page=0", nil)
+	if err := Query.Bind(r, &q); err == nil {
+		t.Error("Query.Bind should validate")
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("page=3"))
+	r.Header.Set("Content-Type", MIMEPOSTForm)
+	b := Default(r.Method, MIMEPOSTForm)
+	if err := b.Bind(r, &q); err != nil || q.Page != 3 {
+		t.Errorf("%s.Bind = %+v, %v", b.Name(), q, err)
+	}
+
+	type uri struct {
+		ID int64 `uri:"id"`
+	}
+	var u uri
+	if err := Uri.BindUri(map[string][]string{"id": {"42"}}, &u); err != nil || u.ID != 42 {
+		t.Errorf("Uri.BindUri = %+v, %v", u, err)
+	}
+
+	type required struct {
+		Token string `header:"x-token" form:"token" required:"true"`
+	}
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	var verrs ValidationErrors
+	for _, b := range []Binding{Header, Query} {
+		if err := b.Bind(r, &required{}); !errors.As(err, &verrs) || verrs[0].Rule != "required" {
+			t.Errorf("%s.Bind without required field: got %v", b.Name(), err)
+		}
+	}
+	if err := Uri.BindUri(map[string][]string{}, &required{}); !errors.As(err, &verrs) {
+		t.Errorf("Uri.BindUri without required field: got %v", err)
+	}
+	// only a missing key fails, unlike validate:"required"
+	r = httptest.NewRequest(http.MethodGet, "/?token=", nil)
+	if err := Query.Bind(r, &required{}); err != nil {
+		t.Errorf("Query.Bind with an empty required field: got %v", err)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		method, contentType string
+		want                Binding
+	}{
+		{http.MethodGet, MIMEJSON, Form},
+		{http.MethodPost, MIMEJSON, JSON},
+		{http.MethodPut, MIMEXML2, XML},
+		{http.MethodPost, MIMEMultipartPOSTForm, FormMultipart},
+		{http.MethodPost, MIMEPOSTForm, Form},
+	}
+	for _, tt := range tests {
+		if got := Default(tt.method, tt.contentType); got.Name() != tt.want.Name() {
+			t.Errorf("Default(%s, %s) = %s, want %s", tt.method, tt.contentType, got.Name(), tt.want.Name())
+		}
+	}
+}
+
+func newMultipartRequest(t *testing.T, files map[string][]string) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	_ = w.WriteField("title", "album")
+	for field, contents := range files {
+		for i, content := range contents {
+			part, err := w.CreateFormFile(field, field+strconv.Itoa(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = part.Write([]byte(content))
+		}
+	}
+	_ = w.Close()
+	r := httptest.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+func TestFormMultipart(t *testing.T) {
+	type album struct {
+		Title  string                  `form:"title"`
+		Cover  *multipart.FileHeader   `form:"cover" file:"max_size=1KB,types=image/png|image/gif"`
+		Photos []*multipart.FileHeader `form:"photo" file:"max_count=2"`
+	}
+	png := "\x89PNG\r\n\x1a\n0000"
+	var obj album
+	r := newMultipartRequest(t, map[string][]string{"cover": {png}, "photo": {"a", "b"}})
+	if err := FormMultipart.Bind(r, &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj.Title != "album" || obj.Cover == nil || obj.Cover.Size != int64(len(png)) || len(obj.Photos) != 2 {
+		t.Errorf("Bind = %+v", obj)
+	}
+
+	tests := []struct {
+		files map[string][]string
+		limit func(b *formMultipartBinding)
+		want  error
+	}{
+		{map[string][]string{"cover": {"plain text"}}, nil, ErrFileType},
+		{map[string][]string{"cover": {png + strings.Repeat("0", 1024)}}, nil, ErrFileTooLarge},
+		{map[string][]string{"photo": {"a", "b", "c"}}, nil, ErrTooManyFiles},
+		{map[string][]string{"photo": {"abc", "abc"}}, func(b *formMultipartBinding) { b.MaxTotalSize = 5 }, ErrFilesTooLarge},
+		{map[string][]string{"photo": {strings.Repeat("0", 2*multipartOverhead)}}, func(b *formMultipartBinding) { b.MaxTotalSize = 5 }, ErrFilesTooLarge},
+		{map[string][]string{"photo": {"abc"}}, func(b *formMultipartBinding) { b.AllowedTypes = []string{"image/*"} }, ErrFileType},
+	}
+	for i, tt := range tests {
+		b := FormMultipart
+		if tt.limit != nil {
+			tt.limit(&b)
+		}
+		err := b.Bind(newMultipartRequest(t, tt.files), &album{})
+		var fileErr *FileError
+		if !errors.Is(err, tt.want) || !errors.As(err, &fileErr) {
+			t.Errorf("%d: err = %v, want %v", i, err, tt.want)
+		}
+	}
+}
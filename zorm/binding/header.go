@@ -0,0 +1,27 @@
+package binding
+
+import (
+	"net/http"
+	"net/textproto"
+)
+
+type headerBinding struct{}
+
+func (headerBinding) Name() string {
+	return "header"
+}
+
+func (headerBinding) Bind(r *http.Request, obj any) error {
+	sent, err := mapping(obj, headerSource(r.Header), "header")
+	if err != nil {
+		return err
+	}
+	return validateSent(obj, sent)
+}
+
+type headerSource map[string][]string
+
+func (s headerSource) values(key string) ([]string, bool) {
+	values, ok := s[textproto.CanonicalMIMEHeaderKey(key)]
+	return values, ok
+}
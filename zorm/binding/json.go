@@ -1,111 +1,113 @@
 package binding
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 )
 
+var ErrBodyTooLarge = errors.New("binding: request body too large")
+
 type jsonBinding struct {
 	DisallowUnknownFields bool
-	IsValidate            bool
+	// IsValidate checks that fields tagged required:"true" were sent and
+	// are not null, in nested structs, pointers and slice elements as well.
+	IsValidate bool
+	// UseNumber decodes numbers into an any as json.Number.
+	UseNumber bool
+	// MaxBodySize limits the body, larger bodies fail with ErrBodyTooLarge.
+	MaxBodySize int64
 }
 
-func (b jsonBinding) Bind(r *http.Request, obj any) error {
-	body := r.Body
-	if body == nil {
-		return errors.New("invalid request")
-	}
-	decoder := json.NewDecoder(body)
-	// todo 两个同时开启需要同时支持 同时开启DisallowUnknownFields会失效
-	if b.DisallowUnknownFields {
-		decoder.DisallowUnknownFields()
+type JSONOption func(b *jsonBinding)
+
+func WithDisallowUnknownFields(disallow bool) JSONOption {
+	return func(b *jsonBinding) {
+		b.DisallowUnknownFields = disallow
 	}
-	if b.IsValidate {
-		err := validateParam(obj, decoder)
-		if err != nil {
-			return err
-		}
-	} else {
-		err := decoder.Decode(obj)
-		if err != nil {
-			return err
-		}
+}
 
+func WithValidate(validate bool) JSONOption {
+	return func(b *jsonBinding) {
+		b.IsValidate = validate
 	}
-	return validate(obj)
 }
 
-func (jsonBinding) Name() string {
-	return "json"
+func WithUseNumber(useNumber bool) JSONOption {
+	return func(b *jsonBinding) {
+		b.UseNumber = useNumber
+	}
 }
 
-func validateParam(obj any, decoder *json.Decoder) error {
-	valueOf := reflect.ValueOf(obj)
-	if valueOf.Kind() != reflect.Pointer {
-		return errors.New("no ptr type")
+func WithMaxBodySize(n int64) JSONOption {
+	return func(b *jsonBinding) {
+		b.MaxBodySize = n
 	}
-	elem := valueOf.Elem().Interface()
-	of := reflect.ValueOf(elem)
-	switch of.Kind() {
-	case reflect.Struct:
-		return checkParam(obj, decoder, of)
-	case reflect.Slice, reflect.Array:
-		elem := of.Type().Elem()
-		if elem.Kind() == reflect.Struct {
-			return checkParamSlice(elem, obj, decoder)
-		}
-		// todo 指针类型支持
-	default:
-		_ = decoder.Decode(obj)
+}
+
+// JSONWith returns the JSON binding with opts applied.
+func JSONWith(opts ...JSONOption) Binding {
+	b := JSON
+	for _, opt := range opts {
+		opt(&b)
 	}
-	return nil
+	return b
 }
 
-func checkParam(obj any, decoder *json.Decoder, of reflect.Value) error {
-	mapValue := make(map[string]interface{})
-	_ = decoder.Decode(&mapValue)
-	for i := 0; i < of.NumField(); i++ {
-		field := of.Type().Field(i)
-		name := field.Name
-		jsonName := field.Tag.Get("json")
-		if jsonName != "" {
-			name = jsonName
-		}
-		required := field.Tag.Get("required")
-		value := mapValue[name]
-		if value == nil && required == "true" {
-			return errors.New(fmt.Sprintf("field [%s] is not exist, because [%s] is required", jsonName, jsonName))
+func (b jsonBinding) Bind(r *http.Request, obj any) error {
+	body := r.Body
+	if body == nil {
+		return errors.New("invalid request")
+	}
+	if b.MaxBodySize > 0 {
+		body = http.MaxBytesReader(nil, body, b.MaxBodySize)
+	}
+	if !b.IsValidate {
+		if err := b.decode(body, obj); err != nil {
+			return bodyError(err)
 		}
+		return validate(obj)
+	}
+	// the body is decoded a second time to see which keys were sent
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return bodyError(err)
+	}
+	if err := b.decode(bytes.NewReader(data), obj); err != nil {
+		return err
 	}
+	var sentBody any
+	if err := json.Unmarshal(data, &sentBody); err != nil {
+		return err
+	}
+	sent := sentFields{}
+	jsonSentFields(reflect.ValueOf(obj), sentBody, sent)
+	return validateSent(obj, sent)
+}
 
-	marshal, _ := json.Marshal(mapValue)
-	_ = json.Unmarshal(marshal, obj)
-	return nil
+func (b jsonBinding) decode(r io.Reader, obj any) error {
+	decoder := json.NewDecoder(r)
+	if b.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if b.UseNumber {
+		decoder.UseNumber()
+	}
+	return decoder.Decode(obj)
 }
 
-func checkParamSlice(of reflect.Type, obj any, decoder *json.Decoder) error {
-	mapValue := make([]map[string]interface{}, 0)
-	_ = decoder.Decode(&mapValue)
-	for i := 0; i < of.NumField(); i++ {
-		field := of.Field(i)
-		name := field.Name
-		jsonName := field.Tag.Get("json")
-		if jsonName != "" {
-			name = jsonName
-		}
-		required := field.Tag.Get("required")
-		for _, v := range mapValue {
-			value := v[name]
-			if value == nil && required == "true" {
-				return errors.New(fmt.Sprintf("field [%s] is not exist, because [%s] is required", jsonName, jsonName))
-			}
-		}
+func bodyError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return fmt.Errorf("%w: limit %d bytes", ErrBodyTooLarge, maxBytesError.Limit)
 	}
+	return err
+}
 
-	marshal, _ := json.Marshal(mapValue)
-	_ = json.Unmarshal(marshal, obj)
-	return nil
+func (jsonBinding) Name() string {
+	return "json"
 }
@@ -0,0 +1,69 @@
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testProfile struct {
+	Email string `json:"email" required:"true"`
+}
+
+type testAccount struct {
+	ID       int            `json:"id" required:"true"`
+	Profile  *testProfile   `json:"profile" required:"true"`
+	Contacts []testProfile  `json:"contacts"`
+	Extra    map[string]any `json:"extra"`
+}
+
+func bindJSON(b Binding, body string, obj any) error {
+	return b.Bind(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), obj)
+}
+
+func TestJSONBinding(t *testing.T) {
+	strict := JSONWith(WithDisallowUnknownFields(true), WithValidate(true))
+
+	var account testAccount
+	err := bindJSON(strict, `{"id":0,"profile":{},"contacts":[{"email":"a"},{"email":null}]}`, &account)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("got %v", err)
+	}
+	var fields []string
+	for _, fe := range verrs {
+		fields = append(fields, fe.Field+" "+fe.Message)
+	}
+	want := []string{"profile.email email is a required field", "contacts[1].email email is a required field"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %q", fields)
+	}
+
+	err = bindJSON(strict, `{"id":1,"profile":{"email":"a"},"unknown":1}`, &testAccount{})
+	if err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("unknown field: got %v", err)
+	}
+	if err := bindJSON(strict, `{"ID":1,"Profile":{"Email":"a"}}`, &testAccount{}); err != nil {
+		t.Errorf("case-insensitive keys: got %v", err)
+	}
+	if err := bindJSON(JSON, `{}`, &testAccount{}); err != nil {
+		t.Errorf("IsValidate off: got %v", err)
+	}
+
+	account = testAccount{}
+	if err := bindJSON(JSONWith(WithUseNumber(true)), `{"extra":{"n":12345678901234567}}`, &account); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := account.Extra["n"].(json.Number); !ok || n.String() != "12345678901234567" {
+		t.Errorf("UseNumber: got %#v", account.Extra["n"])
+	}
+
+	err = bindJSON(JSONWith(WithMaxBodySize(8)), `{"id":123456789}`, &testAccount{})
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("MaxBodySize: got %v", err)
+	}
+}
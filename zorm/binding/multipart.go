@@ -0,0 +1,268 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFileTooLarge  = errors.New("file too large")
+	ErrFilesTooLarge = errors.New("files too large")
+	ErrTooManyFiles  = errors.New("too many files")
+	ErrFileType      = errors.New("file type not allowed")
+)
+
+// multipartOverhead is read on top of MaxTotalSize for the form values and
+// the part headers.
+const multipartOverhead = 1 << 20
+
+var (
+	fileHeaderType      = reflect.TypeOf(multipart.FileHeader{})
+	fileHeaderPtrType   = reflect.TypeOf(&multipart.FileHeader{})
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader{})
+)
+
+// FileError reports an upload that exceeds a limit, errors.Is matches it
+// against ErrFileTooLarge, ErrFilesTooLarge, ErrTooManyFiles or ErrFileType.
+type FileError struct {
+	Field    string
+	Filename string
+	Limit    string
+	Actual   string
+	Err      error
+}
+
+func (e *FileError) Error() string {
+	relation := "exceeds"
+	if errors.Is(e.Err, ErrFileType) {
+		relation = "is not"
+	}
+	if e.Filename != "" {
+		return fmt.Sprintf("binding: field [%s] file [%s]: %s, %s %s %s", e.Field, e.Filename, e.Err, e.Actual, relation, e.Limit)
+	}
+	return fmt.Sprintf("binding: field [%s]: %s, %s %s %s", e.Field, e.Err, e.Actual, relation, e.Limit)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// formMultipartBinding binds values and files of a multipart form. File
+// fields are *multipart.FileHeader or []*multipart.FileHeader, their limits
+// can be narrowed per field with a file tag:
+//
+//	Avatar *multipart.FileHeader `form:"avatar" file:"max_size=2MB,types=image/png|image/jpeg"`
+//	Photos []*multipart.FileHeader `form:"photo" file:"max_count=9,max_total=20MB,types=image/*"`
+//
+// Types are sniffed from the content with http.DetectContentType, the
+// Content-Type sent by the client is not trusted.
+type formMultipartBinding struct {
+	// MaxMemory is passed to ParseMultipartForm, 32MB by default.
+	MaxMemory int64
+	// MaxFileSize limits every file, MaxTotalSize the files of the request.
+	MaxFileSize  int64
+	MaxTotalSize int64
+	// MaxFiles limits the files of one field.
+	MaxFiles     int
+	AllowedTypes []string
+}
+
+func (formMultipartBinding) Name() string {
+	return "multipart/form-data"
+}
+
+func (b formMultipartBinding) Bind(r *http.Request, obj any) error {
+	maxMemory := b.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultMemory
+	}
+	if b.MaxTotalSize > 0 && r.MultipartForm == nil {
+		// stop reading once the limit is passed instead of spooling the
+		// whole body to disk first
+		r.Body = http.MaxBytesReader(nil, r.Body, b.MaxTotalSize+multipartOverhead)
+	}
+	if err := r.ParseMultipartForm(maxMemory); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return &FileError{Field: "*", Limit: strconv.FormatInt(b.MaxTotalSize, 10),
+				Actual: "body over " + strconv.FormatInt(maxErr.Limit, 10), Err: ErrFilesTooLarge}
+		}
+		return err
+	}
+	if b.MaxTotalSize > 0 {
+		var total int64
+		for _, files := range r.MultipartForm.File {
+			for _, file := range files {
+				total += file.Size
+			}
+		}
+		if total > b.MaxTotalSize {
+			return &FileError{Field: "*", Limit: strconv.FormatInt(b.MaxTotalSize, 10),
+				Actual: strconv.FormatInt(total, 10), Err: ErrFilesTooLarge}
+		}
+	}
+	sent, err := mapping(obj, multipartSource{form: r.MultipartForm, binding: b}, "form")
+	if err != nil {
+		return err
+	}
+	return validateSent(obj, sent)
+}
+
+type multipartSource struct {
+	form    *multipart.Form
+	binding formMultipartBinding
+}
+
+func (s multipartSource) values(key string) ([]string, bool) {
+	values, ok := s.form.Value[key]
+	return values, ok
+}
+
+func isFileType(t reflect.Type) bool {
+	return t == fileHeaderType || t == fileHeaderPtrType || t == fileHeaderSliceType
+}
+
+func (s multipartSource) setFiles(v reflect.Value, field reflect.StructField, name string) (bool, error) {
+	files, ok := s.form.File[name]
+	if !ok || len(files) == 0 {
+		return false, nil
+	}
+	limits := fileLimits{
+		maxSize:  s.binding.MaxFileSize,
+		maxCount: s.binding.MaxFiles,
+		types:    s.binding.AllowedTypes,
+	}
+	if err := limits.parse(field.Tag.Get("file")); err != nil {
+		return false, fmt.Errorf("binding: field [%s]: %w", name, err)
+	}
+	if err := limits.check(name, files); err != nil {
+		return false, err
+	}
+	switch v.Type() {
+	case fileHeaderType:
+		v.Set(reflect.ValueOf(*files[0]))
+	case fileHeaderPtrType:
+		v.Set(reflect.ValueOf(files[0]))
+	default:
+		v.Set(reflect.ValueOf(files))
+	}
+	return true, nil
+}
+
+type fileLimits struct {
+	maxSize  int64
+	maxTotal int64
+	maxCount int
+	types    []string
+}
+
+func (l *fileLimits) parse(tag string) error {
+	if tag == "" {
+		return nil
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		var err error
+		switch key {
+		case "max_size":
+			l.maxSize, err = parseSize(value)
+		case "max_total":
+			l.maxTotal, err = parseSize(value)
+		case "max_count":
+			l.maxCount, err = strconv.Atoi(value)
+		case "types":
+			l.types = strings.Split(value, "|")
+		default:
+			err = fmt.Errorf("unknown file option '%s'", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *fileLimits) check(field string, files []*multipart.FileHeader) error {
+	if l.maxCount > 0 && len(files) > l.maxCount {
+		return &FileError{Field: field, Limit: strconv.Itoa(l.maxCount),
+			Actual: strconv.Itoa(len(files)), Err: ErrTooManyFiles}
+	}
+	var total int64
+	for _, file := range files {
+		if l.maxSize > 0 && file.Size > l.maxSize {
+			return &FileError{Field: field, Filename: file.Filename, Limit: strconv.FormatInt(l.maxSize, 10),
+				Actual: strconv.FormatInt(file.Size, 10), Err: ErrFileTooLarge}
+		}
+		total += file.Size
+		if len(l.types) == 0 {
+			continue
+		}
+		contentType, err := sniff(file)
+		if err != nil {
+			return fmt.Errorf("binding: field [%s] file [%s]: %w", field, file.Filename, err)
+		}
+		if !allowedType(contentType, l.types) {
+			return &FileError{Field: field, Filename: file.Filename, Limit: strings.Join(l.types, "|"),
+				Actual: contentType, Err: ErrFileType}
+		}
+	}
+	if l.maxTotal > 0 && total > l.maxTotal {
+		return &FileError{Field: field, Limit: strconv.FormatInt(l.maxTotal, 10),
+			Actual: strconv.FormatInt(total, 10), Err: ErrFilesTooLarge}
+	}
+	return nil
+}
+
+func sniff(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(buf[:n]), ";")
+	return contentType, nil
+}
+
+// allowedType matches exact media types and "image/*" style wildcards.
+func allowedType(contentType string, types []string) bool {
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if t == contentType || strings.HasSuffix(t, "/*") && strings.HasPrefix(contentType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSize reads sizes such as "512", "64KB", "2MB" or "1GB", units are
+// powers of 1024.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	shift := 0
+	for _, unit := range [...]struct {
+		suffix string
+		shift  int
+	}{{"GB", 30}, {"G", 30}, {"MB", 20}, {"M", 20}, {"KB", 10}, {"K", 10}, {"B", 0}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, shift = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.shift
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64>>shift {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	return n << shift, nil
+}
@@ -0,0 +1,17 @@
+package binding
+
+import "net/http"
+
+type queryBinding struct{}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+func (queryBinding) Bind(r *http.Request, obj any) error {
+	sent, err := mapForm(obj, r.URL.Query())
+	if err != nil {
+		return err
+	}
+	return validateSent(obj, sent)
+}
@@ -0,0 +1,159 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+)
+
+var ErrUnsupportedValidator = errors.New("binding: Validator does not use go-playground/validator")
+
+// RegisterValidation adds a rule usable in validate tags, e.g.
+//
+//	binding.RegisterValidation("mobile", func(fl validator.FieldLevel) bool {
+//		return mobileRegexp.MatchString(fl.Field().String())
+//	})
+func RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error {
+	return register(func(v *validator.Validate) error {
+		return v.RegisterValidation(tag, fn, callValidationEvenIfNull...)
+	})
+}
+
+// RegisterStructValidation adds a check of the whole struct for the types
+// of the given values, used for rules that compare several fields.
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) error {
+	return register(func(v *validator.Validate) error {
+		v.RegisterStructValidation(fn, types...)
+		return nil
+	})
+}
+
+// RegisterAlias lets alias stand for tags, e.g. "password" for
+// "required,min=8,max=64".
+func RegisterAlias(alias, tags string) error {
+	return register(func(v *validator.Validate) error {
+		v.RegisterAlias(alias, tags)
+		return nil
+	})
+}
+
+// RegisterTranslation sets the message of tag for a built-in locale, "{0}"
+// is replaced by the field and "{1}" by the rule's parameter.
+func RegisterTranslation(locale, tag, message string) error {
+	translatorMu.RLock()
+	t, ok := translators[strings.ToLower(locale)].(utTranslator)
+	translatorMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("binding: no built-in translator for locale '%s'", locale)
+	}
+	return register(func(v *validator.Validate) error {
+		return v.RegisterTranslation(tag, t.trans, func(trans ut.Translator) error {
+			return trans.Add(tag, message, true)
+		}, func(trans ut.Translator, fe validator.FieldError) string {
+			msg, err := trans.T(tag, fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+			return msg
+		})
+	})
+}
+
+func register(fn func(v *validator.Validate) error) error {
+	if d, ok := Validator.(*defaultValidator); ok {
+		d.lazyInit()
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return fn(d.validate)
+	}
+	v, ok := Validator.Engine().(*validator.Validate)
+	if !ok {
+		return ErrUnsupportedValidator
+	}
+	return fn(v)
+}
+
+// registerRequired turns the required:"true" tag into the requiredTag
+// rule, so both tags are checked and reported the same way. The rules of a
+// struct type must be known before validator caches the type, so the types
+// reachable from t are registered on first use.
+func (d *defaultValidator) registerRequired(t reflect.Type) {
+	if _, ok := d.required.Load(t); ok {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.walkRequired(t)
+}
+
+// walkRequired registers the rules of t and reports whether t has fields
+// tagged required:"true", directly or in nested types. Slices and maps of
+// such types get a dive rule so their elements are checked as well.
+func (d *defaultValidator) walkRequired(t reflect.Type) bool {
+	if v, loaded := d.required.LoadOrStore(t, false); loaded {
+		return v.(bool)
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		has := d.walkRequired(t.Elem())
+		d.required.Store(t, has)
+		return has
+	case reflect.Struct:
+	default:
+		return false
+	}
+	has := false
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("required") == "true" {
+			has = true
+		}
+	}
+	// stored before the fields are walked, recursive types see it
+	d.required.Store(t, has)
+	rules := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		nested := d.walkRequired(field.Type)
+		tag := field.Tag.Get("validate")
+		rule := tag
+		if field.Tag.Get("required") == "true" && !hasRule(rule, requiredTag) {
+			rule = strings.TrimSuffix(requiredTag+","+rule, ",")
+		}
+		if nested && isCollection(field.Type) && !strings.Contains(","+rule+",", ",dive,") {
+			rule = strings.TrimPrefix(rule+",dive", ",")
+		}
+		if rule != tag {
+			rules[field.Name] = rule
+		}
+		has = has || nested
+	}
+	if len(rules) > 0 {
+		d.validate.RegisterStructValidationMapRules(rules, reflect.New(t).Interface())
+	}
+	d.required.Store(t, has)
+	return has
+}
+
+func isCollection(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map
+}
+
+// hasRule reports whether rule applies to the field itself, rules after
+// "dive" apply to the elements.
+func hasRule(tag, rule string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if r == "dive" || r == "keys" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
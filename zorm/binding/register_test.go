@@ -0,0 +1,75 @@
+package binding
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type testSignup struct {
+	Mobile   string   `json:"mobile" validate:"mobile"`
+	Password string   `json:"password" validate:"password"`
+	Confirm  string   `json:"confirm"`
+	Name     string   `json:"name" required:"true"`
+	Tags     []string `json:"tags" required:"true" validate:"max=2"`
+}
+
+func TestRegister(t *testing.T) {
+	mobile := regexp.MustCompile(`^1\d{10}$`)
+	if err := RegisterValidation("mobile", func(fl validator.FieldLevel) bool {
+		return mobile.MatchString(fl.Field().String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterAlias("password", "min=8,max=64"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterStructValidation(func(sl validator.StructLevel) {
+		s := sl.Current().Interface().(testSignup)
+		if s.Password != s.Confirm {
+			sl.ReportError(s.Confirm, "confirm", "Confirm", "eqfield", "password")
+		}
+	}, testSignup{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterTranslation("en", "mobile", "{0} must be a mobile number"); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"mobile":"123","password":"secret","confirm":"other"}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	err := jsonBinding{DisallowUnknownFields: true, IsValidate: true}.Bind(r, &testSignup{})
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("got %v", err)
+	}
+	got := map[string]string{}
+	for _, fe := range verrs {
+		got[fe.Field] = fe.Rule + ": " + fe.Message
+	}
+	want := map[string]string{
+		"mobile":   "mobile: mobile must be a mobile number",
+		"password": "password: password must be at least 8 characters in length",
+		"name":     "required: name is a required field",
+		"tags":     "required: tags is a required field",
+		"confirm":  "eqfield: confirm must be equal to password",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v", got)
+	}
+	for field, msg := range want {
+		if got[field] != msg {
+			t.Errorf("%s: got %q, want %q", field, got[field], msg)
+		}
+	}
+
+	body = `{"mobile":"13800000000","password":"12345678","confirm":"12345678","name":"n","tags":["a"],"extra":1}`
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if err := (jsonBinding{DisallowUnknownFields: true, IsValidate: true}).Bind(r, &testSignup{}); err == nil || !strings.Contains(err.Error(), "extra") {
+		t.Errorf("unknown field: got %v", err)
+	}
+}
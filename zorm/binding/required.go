@@ -0,0 +1,128 @@
+package binding
+
+import (
+	"context"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+)
+
+// requiredTag is the rule required:"true" stands for. Unlike the validator's
+// required it checks that the request sent the field, zero values such as
+// 0, false and "" pass. Failures are reported with the "required" rule.
+const requiredTag = "zorm_required"
+
+type sentKey struct{}
+
+type structKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// sentFields records the fields a binding set from the request, by the
+// address of the struct holding them.
+type sentFields map[structKey]map[string]bool
+
+func (s sentFields) add(v reflect.Value, field string) {
+	if !v.CanAddr() {
+		return
+	}
+	key := structKey{v.UnsafeAddr(), v.Type()}
+	if s[key] == nil {
+		s[key] = map[string]bool{}
+	}
+	s[key][field] = true
+}
+
+// isRequiredSent is the check of requiredTag. Bindings that do not record
+// what was sent, e.g. XML or JSON without IsValidate, leave the tag
+// unchecked.
+func isRequiredSent(ctx context.Context, fl validator.FieldLevel) bool {
+	sent, ok := ctx.Value(sentKey{}).(sentFields)
+	if !ok {
+		return true
+	}
+	parent := fl.Parent()
+	if parent.CanAddr() {
+		return sent[structKey{parent.UnsafeAddr(), parent.Type()}][fl.StructFieldName()]
+	}
+	// e.g. map values, only nil can be told apart from a sent value
+	switch field := fl.Field(); field.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return !field.IsNil()
+	case reflect.Invalid:
+		return false
+	}
+	return true
+}
+
+// validateSent validates obj, checking required:"true" against sent.
+func validateSent(obj any, sent sentFields) error {
+	if d, ok := Validator.(*defaultValidator); ok {
+		return d.validateValue(context.WithValue(context.Background(), sentKey{}, sent), obj)
+	}
+	return validate(obj)
+}
+
+// jsonSentFields records the fields of obj that are present and not null
+// in the decoded body, keys are matched the way encoding/json does.
+func jsonSentFields(v reflect.Value, body any, sent sentFields) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		m, ok := body.(map[string]any)
+		if !ok {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" && field.Anonymous {
+				// the fields of embedded structs are keys of the same object
+				sent.add(v, field.Name)
+				jsonSentFields(v.Field(i), m, sent)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			value := lookupJSON(m, name)
+			if value == nil {
+				continue
+			}
+			sent.add(v, field.Name)
+			jsonSentFields(v.Field(i), value, sent)
+		}
+	case reflect.Slice, reflect.Array:
+		items, _ := body.([]any)
+		for i := 0; i < len(items) && i < v.Len(); i++ {
+			jsonSentFields(v.Index(i), items[i], sent)
+		}
+	}
+}
+
+// lookupJSON matches keys the way encoding/json does, exact first and then
+// case-insensitively.
+func lookupJSON(m map[string]any, name string) any {
+	if v, ok := m[name]; ok {
+		return v
+	}
+	for key, v := range m {
+		if strings.EqualFold(key, name) {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package binding
+
+import (
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the locale of the messages in ValidationErrors.
+var DefaultLocale = "en"
+
+// Translator turns a failed rule into a message.
+type Translator interface {
+	Translate(fe validator.FieldError) string
+}
+
+type TranslatorFunc func(fe validator.FieldError) string
+
+func (f TranslatorFunc) Translate(fe validator.FieldError) string {
+	return f(fe)
+}
+
+var (
+	translatorMu sync.RWMutex
+	translators  = map[string]Translator{}
+)
+
+// RegisterTranslator adds or replaces the translator of a locale such as
+// "en" or "zh", locales are matched case-insensitively.
+func RegisterTranslator(locale string, t Translator) {
+	translatorMu.Lock()
+	translators[strings.ToLower(locale)] = t
+	translatorMu.Unlock()
+}
+
+func translator(locale string) Translator {
+	// the default validator registers the built-in translators on first use
+	Validator.Engine()
+	translatorMu.RLock()
+	defer translatorMu.RUnlock()
+	locale = strings.ToLower(locale)
+	if t, ok := translators[locale]; ok {
+		return t
+	}
+	// "zh-CN" falls back to "zh"
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		if t, ok := translators[base]; ok {
+			return t
+		}
+	}
+	if t, ok := translators[strings.ToLower(DefaultLocale)]; ok {
+		return t
+	}
+	return TranslatorFunc(func(fe validator.FieldError) string {
+		return fe.Error()
+	})
+}
+
+type utTranslator struct {
+	trans ut.Translator
+}
+
+func (t utTranslator) Translate(fe validator.FieldError) string {
+	return fe.Translate(t.trans)
+}
+
+// registerDefaultTranslators registers the English and Chinese messages of
+// validator for v, translators registered before are kept.
+func registerDefaultTranslators(v *validator.Validate) {
+	english, chinese := en.New(), zh.New()
+	uni := ut.New(english, english, chinese)
+	if trans, ok := uni.GetTranslator("en"); ok && enTranslations.RegisterDefaultTranslations(v, trans) == nil {
+		registerRequiredTranslation(v, trans)
+		registerDefaultTranslator("en", utTranslator{trans})
+	}
+	if trans, ok := uni.GetTranslator("zh"); ok && zhTranslations.RegisterDefaultTranslations(v, trans) == nil {
+		registerRequiredTranslation(v, trans)
+		registerDefaultTranslator("zh", utTranslator{trans})
+	}
+}
+
+// registerRequiredTranslation gives requiredTag the message of required.
+func registerRequiredTranslation(v *validator.Validate, trans ut.Translator) {
+	_ = v.RegisterTranslation(requiredTag, trans, func(ut.Translator) error {
+		return nil
+	}, func(trans ut.Translator, fe validator.FieldError) string {
+		msg, err := trans.T("required", fe.Field())
+		if err != nil {
+			return fe.Error()
+		}
+		return msg
+	})
+}
+
+func registerDefaultTranslator(locale string, t Translator) {
+	translatorMu.Lock()
+	if _, ok := translators[locale]; !ok {
+		translators[locale] = t
+	}
+	translatorMu.Unlock()
+}
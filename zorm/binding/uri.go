@@ -0,0 +1,15 @@
+package binding
+
+type uriBinding struct{}
+
+func (uriBinding) Name() string {
+	return "uri"
+}
+
+func (uriBinding) BindUri(params map[string][]string, obj any) error {
+	sent, err := mapping(obj, formSource(params), "uri")
+	if err != nil {
+		return err
+	}
+	return validateSent(obj, sent)
+}
@@ -1,6 +1,8 @@
 package binding
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"reflect"
@@ -18,51 +20,77 @@ var Validator StructValidator = &defaultValidator{}
 type defaultValidator struct {
 	one      sync.Once
 	validate *validator.Validate
+	// mu guards registrations, validator does not allow them to run
+	// concurrently with validation
+	mu       sync.RWMutex
+	required sync.Map
 }
 
+// SliceValidationError holds the errors of the elements of a slice that
+// could not be validated, nil entries passed.
 type SliceValidationError []error
 
 func (err SliceValidationError) Error() string {
-	n := len(err)
-	switch n {
-	case 0:
-		return ""
-	default:
-		var b strings.Builder
-		if err[0] != nil {
-			fmt.Fprintf(&b, "[%d]:%s", 0, err[0].Error())
+	var b strings.Builder
+	for i, e := range err {
+		if e == nil {
+			continue
 		}
-		if n > 1 {
-			for i := 0; i < n; i++ {
-				if err[i] != nil {
-					b.WriteString("\n")
-					fmt.Fprintf(&b, "[%d]:%s", i, err[0].Error())
-				}
-			}
+		if b.Len() > 0 {
+			b.WriteString("\n")
 		}
-		return b.String()
+		fmt.Fprintf(&b, "[%d]:%s", i, e.Error())
 	}
+	return b.String()
 }
 
 func (d *defaultValidator) ValidateStruct(obj any) error {
+	return d.validateValue(context.Background(), obj)
+}
+
+// validateValue keeps structs addressable, required:"true" is looked up
+// by the address of the struct.
+func (d *defaultValidator) validateValue(ctx context.Context, obj any) error {
 	of := reflect.ValueOf(obj)
 	switch of.Kind() {
 	case reflect.Pointer:
-		return d.ValidateStruct(of.Elem().Interface())
+		if of.IsNil() {
+			return nil
+		}
+		if of.Elem().Kind() == reflect.Struct {
+			return d.validateStruct(ctx, obj)
+		}
+		return d.validateValue(ctx, of.Elem().Interface())
 	case reflect.Struct:
-		return d.validateStruct(obj)
+		return d.validateStruct(ctx, obj)
 	case reflect.Slice, reflect.Array:
 		count := of.Len()
-		sliceValidationError := make(SliceValidationError, 0)
+		var errs ValidationErrors
+		sliceValidationError := make(SliceValidationError, count)
+		failed := false
 		for i := 0; i < count; i++ {
-			if err := d.validateStruct(of.Index(i).Interface()); err != nil {
-				sliceValidationError = append(sliceValidationError, err)
+			item := of.Index(i)
+			if item.Kind() == reflect.Struct && item.CanAddr() {
+				item = item.Addr()
+			}
+			err := d.validateValue(ctx, item.Interface())
+			if err == nil {
+				continue
 			}
+			var verrs ValidationErrors
+			if errors.As(err, &verrs) {
+				errs = append(errs, verrs.withPrefix(fmt.Sprintf("[%d]", i))...)
+				continue
+			}
+			sliceValidationError[i] = err
+			failed = true
 		}
-		if len(sliceValidationError) == 0 {
-			return nil
+		if failed {
+			return sliceValidationError
+		}
+		if len(errs) > 0 {
+			return errs
 		}
-		return sliceValidationError
 	}
 	return nil
 }
@@ -75,12 +103,29 @@ func (d *defaultValidator) Engine() any {
 func (d *defaultValidator) lazyInit() {
 	d.one.Do(func() {
 		d.validate = validator.New()
+		d.validate.RegisterTagNameFunc(tagName)
+		_ = d.validate.RegisterValidationCtx(requiredTag, isRequiredSent, true)
+		registerDefaultTranslators(d.validate)
 	})
 }
 
-func (d *defaultValidator) validateStruct(obj any) error {
+func (d *defaultValidator) validateStruct(ctx context.Context, obj any) error {
 	d.lazyInit()
-	return d.validate.Struct(obj)
+	d.registerRequired(reflect.TypeOf(obj))
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return newValidationErrors(d.validate.StructCtx(ctx, obj))
+}
+
+// tagName names fields in errors the way the request spells them.
+func tagName(field reflect.StructField) string {
+	for _, tag := range [...]string{"json", "form", "uri", "header"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
 }
 
 func validate(obj any) error {
@@ -97,6 +97,7 @@ func (cb *CircuitBreaker) NewGeneration() {
 
 func NewCircuitBreaker(st Settings) *CircuitBreaker {
 	cb := &CircuitBreaker{}
+	cb.name = st.Name
 	cb.onStateChange = st.OnStateChange
 	cb.Fallback = st.Fallback
 	if st.MaxRequests == 0 {
@@ -110,7 +111,7 @@ func NewCircuitBreaker(st Settings) *CircuitBreaker {
 		cb.interval = st.Interval
 	}
 	if st.Timeout == 0 {
-		st.Timeout = time.Duration(20) * time.Second
+		cb.timeout = time.Duration(20) * time.Second
 	} else {
 		cb.timeout = st.Timeout
 	}
@@ -149,6 +150,17 @@ func (cb *CircuitBreaker) Execute(req func() (any, error)) (any, error) {
 
 }
 
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// State returns the state of the breaker now, an open breaker whose
+// timeout passed is half-open.
+func (cb *CircuitBreaker) State() Stat {
+	state, _ := cb.currentState(time.Now())
+	return state
+}
+
 func (cb *CircuitBreaker) beforeRequest() (error, uint64) {
 	now := time.Now()
 	state, generation := cb.currentState(now)
@@ -199,7 +211,7 @@ func (cb *CircuitBreaker) SetState(target Stat) {
 	before := cb.state
 	cb.state = target
 	cb.NewGeneration()
-	if cb.onStateChange == nil {
+	if cb.onStateChange != nil {
 		cb.onStateChange(cb.name, before, target)
 	}
 }
@@ -0,0 +1,75 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStateTransitions(t *testing.T) {
+	var changes []Stat
+	cb := NewCircuitBreaker(Settings{
+		Name:    "test",
+		Timeout: 20 * time.Millisecond,
+		ReadyToTrip: func(counts Counts) bool {
+			return counts.ConsecutiveFailures >= 2
+		},
+		OnStateChange: func(name string, from Stat, to Stat) {
+			if name != "test" {
+				t.Errorf("name %q", name)
+			}
+			changes = append(changes, to)
+		},
+	})
+	fail := func() (any, error) {
+		return nil, errors.New("fail")
+	}
+	ok := func() (any, error) {
+		return "ok", nil
+	}
+
+	cb.Execute(fail)
+	cb.Execute(fail)
+	if cb.State() != Open {
+		t.Fatalf("after failures: state %v", cb.State())
+	}
+	if _, err := cb.Execute(ok); err == nil {
+		t.Error("open breaker let a request through")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if cb.State() != HalfOpen {
+		t.Fatalf("after timeout: state %v", cb.State())
+	}
+	cb.Execute(ok)
+	cb.Execute(ok)
+	if cb.State() != Closed {
+		t.Fatalf("after successes: state %v", cb.State())
+	}
+	want := []Stat{Open, HalfOpen, Closed}
+	if len(changes) != len(want) {
+		t.Fatalf("changes %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("changes %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	// no OnStateChange, opening must not call a nil func
+	cb := NewCircuitBreaker(Settings{
+		ReadyToTrip: func(counts Counts) bool {
+			return counts.ConsecutiveFailures >= 1
+		},
+	})
+	cb.Execute(func() (any, error) {
+		return nil, errors.New("fail")
+	})
+	if cb.State() != Open {
+		t.Fatalf("state %v", cb.State())
+	}
+	if cb.timeout != 20*time.Second || time.Until(cb.expiry) < 19*time.Second {
+		t.Errorf("timeout %v, expiry in %v", cb.timeout, time.Until(cb.expiry))
+	}
+}
@@ -1,37 +1,116 @@
 package config
 
 import (
-	"flag"
-	"github.com/BurntSushi/toml"
 	zormlog "github.com/caixr9527/zorm/log"
+	"net"
 	"os"
+	"strconv"
+	"time"
 )
 
+// Conf is the config loaded by Init or the first Current. Reload and Watch
+// leave it unchanged, read Current().Zorm() for the reloaded values.
 var Conf = &ZormConfig{
 	logger: zormlog.Default(),
 }
 
 type ZormConfig struct {
 	logger   *zormlog.Logger
-	Log      map[string]any
-	Pool     map[string]any
-	Template map[string]any
+	Log      LogConfig      `toml:"log"`
+	Pool     PoolConfig     `toml:"pool"`
+	Template TemplateConfig `toml:"template"`
+	Server   ServerConfig   `toml:"server"`
+	Limiter  LimiterConfig  `toml:"limiter"`
 }
 
-func init() {
-	loadToml()
+type LogConfig struct {
+	Path string `toml:"path"`
+	// Level is debug, info or error.
+	Level string `toml:"level" validate:"omitempty,oneof=debug info error"`
 }
 
-func loadToml() {
-	configFile := flag.String("conf", "conf/app.toml", "app config file")
-	flag.Parse()
-	if _, err := os.Stat(*configFile); err != nil {
-		Conf.logger.Error("conf/app.toml file not load, because not exist")
+// LimiterConfig is the [limiter] section used by zorm.LimiterFromConfig,
+// Limit requests per second with bursts of Cap.
+type LimiterConfig struct {
+	Limit int `toml:"limit" validate:"gte=0"`
+	Cap   int `toml:"cap" validate:"gte=0"`
+}
+
+type PoolConfig struct {
+	Cap    int32 `toml:"cap" validate:"gte=0"`
+	Expire int32 `toml:"expire" validate:"gte=0"`
+}
+
+type TemplateConfig struct {
+	Pattern string `toml:"pattern"`
+}
+
+// ServerConfig is the [server] section, durations are written as strings
+// such as "5s".
+type ServerConfig struct {
+	Addr              string        `toml:"addr"`
+	Port              int           `toml:"port" validate:"gte=0,lte=65535"`
+	CertFile          string        `toml:"cert_file"`
+	KeyFile           string        `toml:"key_file"`
+	ReadTimeout       time.Duration `toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `toml:"write_timeout"`
+	IdleTimeout       time.Duration `toml:"idle_timeout"`
+	MaxHeaderBytes    int           `toml:"max_header_bytes"`
+	OpenGateway       bool          `toml:"open_gateway"`
+	// TrustedProxies lists the proxy IPs or CIDRs whose forwarding headers
+	// are trusted when resolving the client IP.
+	TrustedProxies []string `toml:"trusted_proxies"`
+}
+
+func (c ServerConfig) Address() string {
+	if c.Port == 0 {
+		if c.Addr == "" {
+			return ":8080"
+		}
+		return c.Addr
+	}
+	return net.JoinHostPort(c.Addr, strconv.Itoa(c.Port))
+}
+
+// loadDefault loads conf/app.toml (or $ZORM_CONFIG) on the first Current
+// when Init was not called before.
+func loadDefault() {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if current.Load() != nil {
 		return
 	}
-	_, err := toml.DecodeFile(*configFile, Conf)
+	file := os.Getenv("ZORM_CONFIG")
+	if file == "" {
+		file = "conf/app.toml"
+	}
+	err := initLocked(Options{
+		Files:    []string{file},
+		Optional: true,
+		Profile:  os.Getenv("ZORM_PROFILE"),
+	})
 	if err != nil {
-		Conf.logger.Error("conf/app.toml decode fail check format")
-		return
+		Conf.logger.Error(err)
+	}
+}
+
+// Init loads opts into Conf. Without Init the default conf/app.toml (or
+// $ZORM_CONFIG) is loaded by the first Current, so Init is only needed for
+// other files or profiles and must run before that.
+// Reload and Watch read the same files again but only publish the result
+// through Current, use Current().Zorm() to see reloaded values.
+func Init(opts Options) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	return initLocked(opts)
+}
+
+func initLocked(opts Options) error {
+	if err := apply(opts); err != nil {
+		return err
 	}
+	Conf = current.Load().Zorm()
+	options = opts
+	return nil
 }
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"github.com/caixr9527/zorm/binding"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Name   string
+	Server ServerConfig `toml:"server"`
+	Pool   PoolConfig   `toml:"pool"`
+	Tags   []string     `toml:"tags"`
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "app.toml", `
+name = "app"
+tags = ["a", "b"]
+[server]
+port = 8080
+read_timeout = "5s"
+[pool]
+cap = 20
+`)
+	writeFile(t, dir, "app-dev.toml", "[server]\nwriteTimeout = \"7s\"\n")
+	t.Setenv("ZORM_SERVER_IDLE_TIMEOUT", "1m")
+	t.Setenv("ZORM_TAGS", "x, y")
+
+	c, err := Load(Options{
+		Defaults: map[string]any{"server": map[string]any{"addr": "127.0.0.1", "port": 80}},
+		Files:    []string{file, filepath.Join(dir, "missing.json")},
+		Optional: true,
+		Profile:  "dev",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf testConfig
+	if err := c.Unmarshal(&conf); err != nil {
+		t.Fatal(err)
+	}
+	want := testConfig{
+		Name: "app",
+		Server: ServerConfig{
+			Addr:         "127.0.0.1",
+			Port:         8080,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 7 * time.Second,
+			IdleTimeout:  time.Minute,
+		},
+		Pool: PoolConfig{Cap: 20},
+		Tags: []string{"x", "y"},
+	}
+	if !reflect.DeepEqual(conf, want) {
+		t.Errorf("Unmarshal = %+v, want %+v", conf, want)
+	}
+
+	var server ServerConfig
+	if err := c.UnmarshalKey("server", &server); err != nil {
+		t.Fatal(err)
+	}
+	if server.Address() != "127.0.0.1:8080" {
+		t.Errorf("Address() = %q", server.Address())
+	}
+	if got := c.Get("server.idle_timeout"); got != "1m" {
+		t.Errorf("Get(server.idle_timeout) = %v", got)
+	}
+}
+
+func TestLoadYAMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := writeFile(t, dir, "app.yml", "pool:\n  cap: 5\n")
+	jsonFile := writeFile(t, dir, "app.json", `{"pool": {"expire": 30}}`)
+	c, err := Load(Options{Files: []string{yamlFile, jsonFile}, DisableEnv: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pool PoolConfig
+	if err := c.UnmarshalKey("pool", &pool); err != nil {
+		t.Fatal(err)
+	}
+	if pool != (PoolConfig{Cap: 5, Expire: 30}) {
+		t.Errorf("pool = %+v", pool)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(Options{Files: []string{filepath.Join(dir, "app.toml")}}); err == nil {
+		t.Error("missing file without Optional should fail")
+	}
+	file := writeFile(t, dir, "app.toml", "[pool]\ncap = 3000000000\n[server]\nport = 70000\n")
+	c, err := Load(Options{Files: []string{file}, DisableEnv: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pool PoolConfig
+	if err := c.UnmarshalKey("pool", &pool); err == nil || !strings.Contains(err.Error(), "pool.cap") {
+		t.Errorf("overflow error = %v", err)
+	}
+	var server ServerConfig
+	if err := c.UnmarshalKey("server", &server); err != nil {
+		t.Errorf("without Validate: %v", err)
+	}
+	Validate = binding.Validator.ValidateStruct
+	defer func() { Validate = nil }()
+	if err := c.UnmarshalKey("server", &server); err == nil {
+		t.Error("port out of range should fail validation")
+	}
+}
+
+func TestCurrentLoadsDefault(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ZORM_CONFIG", writeFile(t, dir, "app.toml", "[pool]\ncap = 7\n"))
+	current.Store(nil)
+	defaultOnce = sync.Once{}
+	conf := Conf
+	defer func() { Conf = conf }()
+
+	if current.Load() != nil {
+		t.Fatal("config loaded before Current")
+	}
+	if Current().Zorm().Pool.Cap != 7 || Conf.Pool.Cap != 7 {
+		t.Errorf("pool cap = %d, Conf = %d", Current().Zorm().Pool.Cap, Conf.Pool.Cap)
+	}
+
+	// Init before the first Current wins over the default file
+	current.Store(nil)
+	defaultOnce = sync.Once{}
+	file := writeFile(t, dir, "other.toml", "[pool]\ncap = 9\n")
+	if err := Init(Options{Files: []string{file}, DisableEnv: true}); err != nil {
+		t.Fatal(err)
+	}
+	if Current().Zorm().Pool.Cap != 9 {
+		t.Errorf("pool cap = %d, want 9", Current().Zorm().Pool.Cap)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "app.toml", "[server]\nport = 8080\n[pool]\ncap = 1\n")
+	if err := Init(Options{Files: []string{file}, DisableEnv: true}); err != nil {
+		t.Fatal(err)
+	}
+	changes := make(chan int, 4)
+	cancel := OnChange("server", func(old, new *Config) {
+		var server ServerConfig
+		if err := new.UnmarshalKey("server", &server); err != nil {
+			t.Error(err)
+		}
+		changes <- server.Port
+	})
+	defer cancel()
+	stop := Watch(10 * time.Millisecond)
+	defer stop()
+
+	// pool only, the server subscriber must not fire
+	writeFile(t, dir, "app.toml", "[server]\nport = 8080\n[pool]\ncap = 2\n")
+	waitFor(t, func() bool { return Current().Get("pool.cap") == int64(2) })
+	if Current().Zorm().Pool.Cap != 2 || Conf.Pool.Cap != 1 {
+		t.Errorf("pool cap = %d, Conf = %d", Current().Zorm().Pool.Cap, Conf.Pool.Cap)
+	}
+	writeFile(t, dir, "app.toml", "[server]\nport = 9090\n[pool]\ncap = 2\n")
+	select {
+	case port := <-changes:
+		if port != 9090 {
+			t.Errorf("port = %d, want 9090", port)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnChange was not called")
+	}
+
+	writeFile(t, dir, "app.toml", "[server\nport = 1\n")
+	if err := Reload(); err == nil {
+		t.Error("Reload of a broken file should fail")
+	}
+	if Current().Get("server.port") != int64(9090) {
+		t.Errorf("broken file replaced the last good config")
+	}
+	select {
+	case port := <-changes:
+		t.Errorf("unexpected change to %d", port)
+	default:
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
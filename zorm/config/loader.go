@@ -0,0 +1,477 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const DefaultEnvPrefix = "ZORM_"
+
+// Validate checks the structs decoded by Unmarshal and UnmarshalKey, nil
+// skips the check. The zorm package sets it to binding.Validator.
+var Validate func(obj any) error
+
+// Options describes the layers merged by Load, later layers win: Defaults,
+// Files in order, the profile overlay of each file and finally environment
+// variables.
+type Options struct {
+	// Defaults holds values used when no layer sets them, nested sections
+	// are written as map[string]any.
+	Defaults map[string]any
+	// Files are .toml, .yaml, .yml or .json files.
+	Files []string
+	// Optional skips files that do not exist instead of failing.
+	Optional bool
+	// Profile loads "app-<profile>.toml" next to "app.toml" when present.
+	Profile string
+	// EnvPrefix defaults to DefaultEnvPrefix, a field at server.read_timeout
+	// is overridden by ZORM_SERVER_READ_TIMEOUT.
+	EnvPrefix  string
+	DisableEnv bool
+}
+
+// Config is the merged result of Load.
+type Config struct {
+	values     map[string]any
+	envPrefix  string
+	disableEnv bool
+	zorm       *ZormConfig
+}
+
+func Load(opts Options) (*Config, error) {
+	c := &Config{
+		values:     map[string]any{},
+		envPrefix:  opts.EnvPrefix,
+		disableEnv: opts.DisableEnv,
+	}
+	if c.envPrefix == "" {
+		c.envPrefix = DefaultEnvPrefix
+	}
+	merge(c.values, opts.Defaults)
+	for _, file := range opts.Files {
+		values, err := readFile(file)
+		if err != nil {
+			if opts.Optional && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		merge(c.values, values)
+		if opts.Profile == "" {
+			continue
+		}
+		values, err = readFile(profileFile(file, opts.Profile))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		merge(c.values, values)
+	}
+	return c, nil
+}
+
+func profileFile(file, profile string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + profile + ext
+}
+
+func readFile(file string) (map[string]any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]any{}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	default:
+		return nil, fmt.Errorf("config: unsupported file type [%s]", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: decode [%s]: %w", file, err)
+	}
+	return values, nil
+}
+
+// Get returns the value at a dotted key such as "server.port", environment
+// variables take precedence over the files.
+func (c *Config) Get(key string) any {
+	if value, ok := c.env(strings.Split(key, ".")); ok {
+		return value
+	}
+	value, _ := c.lookup(key)
+	return value
+}
+
+// Unmarshal decodes the whole config into target and validates it with
+// Validate.
+func (c *Config) Unmarshal(target any) error {
+	return c.unmarshal(nil, c.values, target)
+}
+
+// UnmarshalKey decodes the section at a dotted key into target.
+func (c *Config) UnmarshalKey(key string, target any) error {
+	value, _ := c.lookup(key)
+	return c.unmarshal(strings.Split(key, "."), value, target)
+}
+
+func (c *Config) unmarshal(path []string, value any, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("config: unmarshal target must be a non-nil pointer, got %T", target)
+	}
+	if err := c.decode(path, value, v.Elem()); err != nil {
+		return err
+	}
+	if v.Elem().Kind() != reflect.Struct || Validate == nil {
+		return nil
+	}
+	return Validate(target)
+}
+
+func (c *Config) lookup(key string) (any, bool) {
+	var value any = c.values
+	for _, name := range strings.Split(key, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = get(m, name); !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func (c *Config) env(path []string) (string, bool) {
+	if c.disableEnv || len(path) == 0 {
+		return "", false
+	}
+	names := make([]string, len(path))
+	for i, name := range path {
+		names[i] = strings.ToUpper(snake(name))
+	}
+	return os.LookupEnv(c.envPrefix + strings.Join(names, "_"))
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func (c *Config) decode(path []string, value any, v reflect.Value) error {
+	if v.Kind() != reflect.Struct && v.Kind() != reflect.Map || v.Type().Implements(textUnmarshalerType) ||
+		reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		if env, ok := c.env(path); ok {
+			value = env
+		}
+	}
+	if value == nil {
+		if v.Kind() == reflect.Struct {
+			return c.decodeStruct(path, nil, v)
+		}
+		return nil
+	}
+	if s, ok := value.(string); ok && v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return decodeError(path, value, v, err)
+		}
+		return nil
+	}
+	if v.Type() == durationType {
+		return c.decodeDuration(path, value, v)
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return c.decode(path, value, v.Elem())
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(value))
+	case reflect.String:
+		switch value := value.(type) {
+		case string:
+			v.SetString(value)
+		case bool, int64, float64, json.Number, int:
+			v.SetString(fmt.Sprint(value))
+		default:
+			return decodeError(path, value, v, nil)
+		}
+	case reflect.Bool:
+		switch value := value.(type) {
+		case bool:
+			v.SetBool(value)
+		case string:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return decodeError(path, value, v, err)
+			}
+			v.SetBool(b)
+		default:
+			return decodeError(path, value, v, nil)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt(value)
+		if err != nil || v.OverflowInt(n) {
+			return decodeError(path, value, v, err)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt(value)
+		if err != nil || n < 0 || v.OverflowUint(uint64(n)) {
+			return decodeError(path, value, v, err)
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(value)
+		if err != nil || v.OverflowFloat(f) {
+			return decodeError(path, value, v, err)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		return c.decodeSlice(path, value, v)
+	case reflect.Map:
+		return c.decodeMap(path, value, v)
+	case reflect.Struct:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return decodeError(path, value, v, nil)
+		}
+		return c.decodeStruct(path, m, v)
+	default:
+		return decodeError(path, value, v, nil)
+	}
+	return nil
+}
+
+func (c *Config) decodeStruct(path []string, m map[string]any, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		value, _ := get(m, name)
+		if err := c.decode(append(path[:len(path):len(path)], name), value, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) decodeSlice(path []string, value any, v reflect.Value) error {
+	var items []any
+	switch value := value.(type) {
+	case []any:
+		items = value
+	case []map[string]any:
+		for _, item := range value {
+			items = append(items, item)
+		}
+	case string:
+		// environment variables hold lists as "a,b,c"
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	default:
+		return decodeError(path, value, v, nil)
+	}
+	slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		itemPath := append(path[:len(path):len(path)], strconv.Itoa(i))
+		if err := c.decode(itemPath, item, slice.Index(i)); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+func (c *Config) decodeMap(path []string, value any, v reflect.Value) error {
+	m, ok := value.(map[string]any)
+	if !ok || v.Type().Key().Kind() != reflect.String {
+		return decodeError(path, value, v, nil)
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+	}
+	for key, item := range m {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := c.decode(append(path[:len(path):len(path)], key), item, elem); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+	}
+	return nil
+}
+
+func (c *Config) decodeDuration(path []string, value any, v reflect.Value) error {
+	if s, ok := value.(string); ok {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return decodeError(path, value, v, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	n, err := toInt(value)
+	if err != nil {
+		return decodeError(path, value, v, err)
+	}
+	v.SetInt(n)
+	return nil
+}
+
+func toInt(value any) (int64, error) {
+	switch value := value.(type) {
+	case int64:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case float64:
+		if value != math.Trunc(value) || value > math.MaxInt64 || value < math.MinInt64 {
+			return 0, fmt.Errorf("%v is not an integer", value)
+		}
+		return int64(value), nil
+	case json.Number:
+		return value.Int64()
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(value), 0, 64)
+	}
+	return 0, fmt.Errorf("%T is not a number", value)
+}
+
+func toFloat(value any) (float64, error) {
+	switch value := value.(type) {
+	case float64:
+		return value, nil
+	case int64:
+		return float64(value), nil
+	case int:
+		return float64(value), nil
+	case json.Number:
+		return value.Float64()
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	}
+	return 0, fmt.Errorf("%T is not a number", value)
+}
+
+func decodeError(path []string, value any, v reflect.Value, err error) error {
+	if err != nil {
+		return fmt.Errorf("config: cannot decode %v into %s at [%s]: %w", value, v.Type(), strings.Join(path, "."), err)
+	}
+	return fmt.Errorf("config: cannot decode %v into %s at [%s]", value, v.Type(), strings.Join(path, "."))
+}
+
+// fieldName prefers the config tag, then toml, yaml and json tags and falls
+// back to the field name.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range [...]string{"config", "toml", "yaml", "json"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// merge deep merges src into dst, keys are matched ignoring case, '_' and '-'
+// so "read_timeout" in one layer overrides "readTimeout" in another.
+func merge(dst, src map[string]any) {
+	for key, value := range src {
+		existing, found := findKey(dst, key)
+		if srcMap, ok := value.(map[string]any); ok {
+			dstMap, ok := dst[existing].(map[string]any)
+			if !found || !ok {
+				dstMap = map[string]any{}
+			}
+			merge(dstMap, srcMap)
+			value = dstMap
+		}
+		if found {
+			delete(dst, existing)
+		}
+		dst[key] = value
+	}
+}
+
+func get(m map[string]any, name string) (any, bool) {
+	key, ok := findKey(m, name)
+	if !ok {
+		return nil, false
+	}
+	return m[key], true
+}
+
+func findKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	normalized := normalize(name)
+	for key := range m {
+		if normalize(key) == normalized {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func normalize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// snake turns ReadTimeout into read_timeout, names that already contain an
+// underscore are kept.
+func snake(name string) string {
+	if strings.ContainsRune(name, '_') {
+		return strings.ReplaceAll(name, "-", "_")
+	}
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '-' {
+			b.WriteByte('_')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
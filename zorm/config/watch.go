@@ -0,0 +1,193 @@
+package config
+
+import (
+	"crypto/sha256"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	reloadMu    sync.Mutex
+	options     Options
+	current     atomic.Pointer[Config]
+	subscribers []*subscriber
+	defaultOnce sync.Once
+)
+
+type subscriber struct {
+	section string
+	fn      func(old, new *Config)
+}
+
+// Current returns the config last loaded by Init, Reload or Watch, the first
+// call loads the default file when Init was not called. Unlike Conf it is
+// safe to read while a Watch goroutine is running.
+func Current() *Config {
+	if c := current.Load(); c != nil {
+		return c
+	}
+	defaultOnce.Do(loadDefault)
+	return current.Load()
+}
+
+// Zorm returns the framework sections of c, or Conf when c is nil.
+func (c *Config) Zorm() *ZormConfig {
+	if c == nil || c.zorm == nil {
+		return Conf
+	}
+	return c.zorm
+}
+
+// OnChange registers fn to be called after a reload changed the given dotted
+// section, an empty section matches any change. Callbacks run on the
+// reloading goroutine with the previous and the new config and must not call
+// Init or Reload. The returned function removes the subscription.
+func OnChange(section string, fn func(old, new *Config)) (cancel func()) {
+	s := &subscriber{section: section, fn: fn}
+	reloadMu.Lock()
+	subscribers = append(subscribers, s)
+	reloadMu.Unlock()
+	return func() {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+		for i, sub := range subscribers {
+			if sub == s {
+				subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Reload reads the files passed to Init, or the default file, again. When
+// loading, decoding or validation fails the last good config is kept and the
+// error returned.
+func Reload() error {
+	Current()
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	return apply(options)
+}
+
+func apply(opts Options) error {
+	c, err := Load(opts)
+	if err != nil {
+		return err
+	}
+	conf := &ZormConfig{logger: Conf.logger}
+	if err := c.Unmarshal(conf); err != nil {
+		return err
+	}
+	c.zorm = conf
+	old := current.Swap(c)
+	if old == nil {
+		return nil
+	}
+	for _, s := range subscribers {
+		if !reflect.DeepEqual(old.section(s.section), c.section(s.section)) {
+			s.fn(old, c)
+		}
+	}
+	return nil
+}
+
+func (c *Config) section(key string) any {
+	if key == "" {
+		return c.values
+	}
+	value, _ := c.lookup(key)
+	return value
+}
+
+// Watch polls the config files every interval and reloads them when their
+// content changed, a file that is only touched does not trigger a reload.
+// Errors are logged and the last good config stays active. Call the returned
+// function to stop watching.
+func Watch(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	states := map[string]fileState{}
+	watchedFiles(states)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if !watchedFiles(states) {
+				continue
+			}
+			if err := Reload(); err != nil {
+				Current().Zorm().logger.Error(err)
+			}
+		}
+	}()
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+// watchedFiles refreshes states for the files and profile overlays of the
+// current options and reports whether the content of any of them changed.
+func watchedFiles(states map[string]fileState) bool {
+	reloadMu.Lock()
+	opts := options
+	reloadMu.Unlock()
+	changed := false
+	seen := map[string]bool{}
+	for _, file := range opts.Files {
+		files := []string{file}
+		if opts.Profile != "" {
+			files = append(files, profileFile(file, opts.Profile))
+		}
+		for _, file := range files {
+			seen[file] = true
+			if fileChanged(file, states) {
+				changed = true
+			}
+		}
+	}
+	for file := range states {
+		if !seen[file] {
+			delete(states, file)
+			changed = true
+		}
+	}
+	return changed
+}
+
+func fileChanged(file string, states map[string]fileState) bool {
+	old, existed := states[file]
+	info, err := os.Stat(file)
+	if err != nil {
+		delete(states, file)
+		return existed
+	}
+	// mtime has a coarse resolution on some file systems, a write right
+	// after the last poll may keep it, so recent files are always hashed
+	if existed && info.ModTime().Equal(old.modTime) && info.Size() == old.size &&
+		time.Since(info.ModTime()) > 2*time.Second {
+		return false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	state := fileState{modTime: info.ModTime(), size: info.Size(), hash: sha256.Sum256(data)}
+	states[file] = state
+	return !existed || state.hash != old.hash
+}
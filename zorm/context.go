@@ -1,6 +1,7 @@
 package zorm
 
 import (
+	"context"
 	"errors"
 	"github.com/caixr9527/zorm/binding"
 	zormLog "github.com/caixr9527/zorm/log"
@@ -8,29 +9,104 @@ import (
 	"html/template"
 	"io"
 	"log"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 const defaultMaxMemory = 32 << 20
 
+const abortIndex = math.MaxInt >> 1
+
+var _ context.Context = (*Context)(nil)
+
 type Context struct {
-	W                     http.ResponseWriter
+	W                     ResponseWriter
+	writer                responseWriter
 	R                     *http.Request
 	engine                *Engine
 	queryParams           url.Values
 	formParams            url.Values
 	DisallowUnknownFields bool
 	IsValidate            bool
-	StatusCode            int
 	Logger                *zormLog.Logger
 	Keys                  map[string]any
 	mu                    sync.RWMutex
 	sameSite              http.SameSite
+	params                Params
+	fullPath              string
+	handlers              HandlersChain
+	index                 int
+
+	// Deprecated: use W.Status. StatusCode follows the status written
+	// through the engine's ResponseWriter.
+	StatusCode int
+}
+
+func (c *Context) reset() {
+	c.queryParams = nil
+	c.formParams = nil
+	c.DisallowUnknownFields = false
+	c.IsValidate = false
+	c.Keys = nil
+	c.sameSite = 0
+	c.params = c.params[:0]
+	c.fullPath = ""
+	c.handlers = nil
+	c.index = -1
+}
+
+// Next runs the remaining handlers of the chain. It is meant to be called in
+// middlewares, code after Next runs once the rest of the chain returns.
+func (c *Context) Next() {
+	c.index++
+	for c.index < len(c.handlers) {
+		c.handlers[c.index](c)
+		c.index++
+	}
+}
+
+// Abort prevents the pending handlers of the chain from running, the current
+// handler still returns normally.
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+func (c *Context) AbortWithStatus(code int) {
+	c.Abort()
+	c.W.WriteHeader(code)
+}
+
+func (c *Context) AbortWithStatusJSON(code int, data any) error {
+	c.Abort()
+	return c.JSON(code, data)
+}
+
+// FullPath returns the pattern of the matched route, e.g. "/user/:id", or
+// "" when no route matched.
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
+func (c *Context) Param(key string) string {
+	return c.params.ByName(key)
+}
+
+func (c *Context) Params() Params {
+	return c.params
+}
+
+func (c *Context) CatchAll() string {
+	return c.params.ByName("**")
 }
 
 func (c *Context) SetSamSite(s http.SameSite) {
@@ -53,6 +129,46 @@ func (c *Context) Get(key string) (value any, ok bool) {
 	return
 }
 
+// Deadline, Done, Err and Value make Context a context.Context backed by
+// the request's context, so it can be passed to database and RPC calls and
+// is cancelled when the client goes away. The Context is reused once the
+// request ends, goroutines outliving it should use c.R.Context() instead.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.R == nil {
+		return
+	}
+	return c.R.Context().Deadline()
+}
+
+func (c *Context) Done() <-chan struct{} {
+	if c.R == nil {
+		return nil
+	}
+	return c.R.Context().Done()
+}
+
+func (c *Context) Err() error {
+	if c.R == nil {
+		return nil
+	}
+	return c.R.Context().Err()
+}
+
+// Value returns the value of the request context, string keys not found
+// there are looked up in Keys.
+func (c *Context) Value(key any) any {
+	if c.R != nil {
+		if value := c.R.Context().Value(key); value != nil {
+			return value
+		}
+	}
+	if k, ok := key.(string); ok {
+		value, _ := c.Get(k)
+		return value
+	}
+	return nil
+}
+
 func (c *Context) SetBasicAuth(username, password string) {
 	c.R.Header.Set("Authorization", "Basic "+BasicAuth(username, password))
 }
@@ -87,7 +203,7 @@ func (c *Context) initQueryParams() {
 
 func (c *Context) initPostFormParams() {
 	if c.R != nil {
-		if err := c.R.ParseMultipartForm(defaultMaxMemory); err != nil {
+		if err := c.R.ParseMultipartForm(c.engine.maxMultipartMemory()); err != nil {
 			if !errors.Is(err, http.ErrNotMultipart) {
 				log.Println(err)
 			}
@@ -140,22 +256,29 @@ func (c *Context) get(params map[string][]string, key string) (map[string]string
 	return dicts, exist
 }
 
+// FormFile returns the first file of name, nil when the form has none.
 func (c *Context) FormFile(name string) *multipart.FileHeader {
-	file, header, err := c.R.FormFile(name)
+	files, err := c.FormFiles(name)
 	if err != nil {
 		log.Println(err)
+		return nil
 	}
-	defer file.Close()
-	return header
+	if len(files) == 0 {
+		return nil
+	}
+	return files[0]
 }
 
 func (c *Context) FormFiles(name string) ([]*multipart.FileHeader, error) {
 	multipartForm, err := c.MultipartForm()
-	return multipartForm.File[name], err
+	if err != nil {
+		return nil, err
+	}
+	return multipartForm.File[name], nil
 }
 
 func (c *Context) MultipartForm() (*multipart.Form, error) {
-	err := c.R.ParseMultipartForm(defaultMaxMemory)
+	err := c.R.ParseMultipartForm(c.engine.maxMultipartMemory())
 	return c.R.MultipartForm, err
 }
 
@@ -174,17 +297,77 @@ func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error
 	return err
 }
 
-func (c *Context) BindJson(obj any) error {
-	jsonBinding := binding.JSON
-	jsonBinding.DisallowUnknownFields = true
-	jsonBinding.IsValidate = true
-	return c.MustBindWith(obj, jsonBinding)
+// BindJson decodes the body as JSON, DisallowUnknownFields and IsValidate
+// of the context set the defaults that opts can override.
+func (c *Context) BindJson(obj any, opts ...binding.JSONOption) error {
+	return c.MustBindWith(obj, c.jsonBinding(opts...))
+}
+
+func (c *Context) jsonBinding(opts ...binding.JSONOption) binding.Binding {
+	flags := []binding.JSONOption{
+		binding.WithDisallowUnknownFields(c.DisallowUnknownFields),
+		binding.WithValidate(c.IsValidate),
+	}
+	return binding.JSONWith(append(flags, opts...)...)
 }
 
 func (c *Context) BindXML(obj any) error {
 	return c.MustBindWith(obj, binding.XML)
 }
 
+// Bind picks the binding from the method and Content-Type of the request.
+func (c *Context) Bind(obj any) error {
+	return c.MustBindWith(obj, c.defaultBinding())
+}
+
+func (c *Context) BindQuery(obj any) error {
+	return c.MustBindWith(obj, binding.Query)
+}
+
+func (c *Context) BindHeader(obj any) error {
+	return c.MustBindWith(obj, binding.Header)
+}
+
+// BindUri binds the route params using the uri tag.
+func (c *Context) BindUri(obj any) error {
+	if err := c.ShouldBindUri(obj); err != nil {
+		c.bindError(err)
+		return err
+	}
+	return nil
+}
+
+func (c *Context) ShouldBind(obj any) error {
+	return c.ShouldBindWith(obj, c.defaultBinding())
+}
+
+func (c *Context) defaultBinding() binding.Binding {
+	b := binding.Default(c.R.Method, c.ContentType())
+	if b.Name() == binding.JSON.Name() {
+		return c.jsonBinding()
+	}
+	if b.Name() == binding.FormMultipart.Name() && binding.FormMultipart.MaxMemory == 0 {
+		multipartBinding := binding.FormMultipart
+		multipartBinding.MaxMemory = c.engine.maxMultipartMemory()
+		return multipartBinding
+	}
+	return b
+}
+
+func (c *Context) ShouldBindUri(obj any) error {
+	params := make(map[string][]string, len(c.params))
+	for _, p := range c.params {
+		params[p.Key] = []string{p.Value}
+	}
+	return binding.Uri.BindUri(params, obj)
+}
+
+// ContentType returns the Content-Type header without parameters.
+func (c *Context) ContentType() string {
+	contentType, _, _ := strings.Cut(c.GetHeader("Content-Type"), ";")
+	return strings.TrimSpace(contentType)
+}
+
 func (c *Context) HTML(status int, html string) error {
 	return c.Render(status, &render.HTML{Data: html, IsTemplate: false})
 }
@@ -232,6 +415,79 @@ func (c *Context) XML(status int, data any) error {
 	return c.Render(status, &render.XML{Data: data})
 }
 
+func (c *Context) IndentedJSON(status int, data any) error {
+	return c.Render(status, &render.IndentedJSON{Data: data})
+}
+
+// SecureJSON prefixes arrays with Engine.SecureJSONPrefix.
+func (c *Context) SecureJSON(status int, data any) error {
+	return c.Render(status, &render.SecureJSON{Prefix: c.engine.SecureJSONPrefix, Data: data})
+}
+
+// JSONP wraps the JSON in the function named by the callback query param,
+// callbacks that are not a JavaScript identifier or member path are ignored.
+func (c *Context) JSONP(status int, data any) error {
+	return c.Render(status, &render.JsonpJSON{Callback: c.GetQuery("callback"), Data: data})
+}
+
+func (c *Context) AsciiJSON(status int, data any) error {
+	return c.Render(status, &render.AsciiJSON{Data: data})
+}
+
+func (c *Context) YAML(status int, data any) error {
+	return c.Render(status, &render.YAML{Data: data})
+}
+
+func (c *Context) TOML(status int, data any) error {
+	return c.Render(status, &render.TOML{Data: data})
+}
+
+func (c *Context) ProtoBuf(status int, data any) error {
+	return c.Render(status, &render.ProtoBuf{Data: data})
+}
+
+func (c *Context) MsgPack(status int, data any) error {
+	return c.Render(status, &render.MsgPack{Data: data})
+}
+
+// SSEvent sends a Server-Sent Event named name and flushes it.
+func (c *Context) SSEvent(name string, data any) error {
+	code := -1
+	if !c.W.Written() {
+		code = http.StatusOK
+	}
+	if err := c.Render(code, &render.SSEvent{Event: name, Data: data}); err != nil {
+		return err
+	}
+	c.Flush()
+	return nil
+}
+
+// Stream calls step and flushes what it wrote until step returns false or
+// the client goes away, in which case it returns true. A step waiting for
+// data should also watch c.R.Context().Done().
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.R.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c.W)
+			c.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
+// Flush sends the buffered response to the client if the underlying
+// ResponseWriter supports it.
+func (c *Context) Flush() {
+	c.W.Flush()
+}
+
 func (c *Context) File(filename string) {
 	http.ServeFile(c.W, c.R, filename)
 }
@@ -263,20 +519,62 @@ func (c *Context) String(status int, format string, values ...any) error {
 	return c.Render(status, &render.String{Format: format, Data: values})
 }
 
+// Render writes r with statusCode, a statusCode <= 0 keeps the status
+// already sent. Statuses that don't allow a body only get the header.
 func (c *Context) Render(statusCode int, r render.Render) error {
-	err := r.Render(c.W, statusCode)
-	c.StatusCode = statusCode
-	return err
+	if !bodyAllowedForStatus(statusCode) {
+		r.WriteContentType(c.W)
+		c.W.WriteHeader(statusCode)
+		return nil
+	}
+	return r.Render(c.W, statusCode)
 }
 
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// MustBindWith answers 400 when binding fails, validation errors are
+// translated to the Accept-Language of the request and rendered by the
+// engine's ErrorHandler.
 func (c *Context) MustBindWith(obj any, bind binding.Binding) error {
 	if err := c.ShouldBindWith(obj, bind); err != nil {
-		c.W.WriteHeader(http.StatusBadRequest)
+		c.bindError(err)
 		return err
 	}
 	return nil
 }
 
+func (c *Context) bindError(err error) {
+	var verrs binding.ValidationErrors
+	if !errors.As(err, &verrs) {
+		if errors.Is(err, binding.ErrBodyTooLarge) {
+			c.W.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.W.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if locale := c.acceptLanguage(); locale != "" {
+		err = verrs.Translate(locale)
+	}
+	code, data := c.engine.errorHandler(err)
+	_ = c.JSON(code, data)
+}
+
+// acceptLanguage returns the first language of the Accept-Language header.
+func (c *Context) acceptLanguage() string {
+	lang, _, _ := strings.Cut(c.GetHeader("Accept-Language"), ",")
+	lang, _, _ = strings.Cut(lang, ";")
+	return strings.TrimSpace(lang)
+}
+
 func (c *Context) ShouldBindWith(obj any, bind binding.Binding) error {
 	return bind.Bind(c.R, obj)
 }
@@ -1,75 +1,76 @@
 package gateway
 
-import "strings"
+import (
+	"github.com/caixr9527/zorm/internal/radix"
+	"strings"
+)
 
+// TreeNode maps gateway paths to the names of their GWConfig. Matching is
+// done by a radix tree, the exported fields keep the segment view of the
+// registered paths.
 type TreeNode struct {
 	Name       string
 	Children   []*TreeNode
 	RouterName string
 	IsEnd      bool
 	GwName     string
+	tree       radix.Tree[*TreeNode]
 }
 
-func (t *TreeNode) Put(path string, gwName string) {
-	root := t
-	strs := strings.Split(path, "/")
-	for index, name := range strs {
-		if index == 0 {
-			continue
-		}
-		children := t.Children
-		isMatch := false
-		for _, node := range children {
-			if node.Name == name {
-				isMatch = true
-				t = node
+// Add registers path for gwName, it returns an error when path conflicts
+// with a path already added. Only the node ending path gets GwName.
+func (t *TreeNode) Add(path string, gwName string) error {
+	names := strings.Split(path, "/")[1:]
+	parent := t
+	for len(names) > 0 {
+		var next *TreeNode
+		for _, child := range parent.Children {
+			if child.Name == names[0] {
+				next = child
 				break
 			}
 		}
-		if !isMatch {
-			isEnd := false
-			if index == len(strs)-1 {
-				isEnd = true
-			}
-			node := &TreeNode{Name: name, Children: make([]*TreeNode, 0), IsEnd: isEnd, GwName: gwName}
-			children = append(children, node)
-			t.Children = children
-			t = node
+		if next == nil {
+			break
+		}
+		parent = next
+		names = names[1:]
+	}
+	// the missing segments are linked in once the radix tree accepted path
+	end := parent
+	var first *TreeNode
+	for _, name := range names {
+		node := &TreeNode{Name: name, Children: make([]*TreeNode, 0)}
+		if first == nil {
+			first = node
+		} else {
+			end.Children = append(end.Children, node)
 		}
+		end = node
 	}
-	t = root
+	if err := t.tree.Add(path, end); err != nil {
+		return err
+	}
+	if first != nil {
+		parent.Children = append(parent.Children, first)
+	}
+	end.IsEnd = true
+	end.RouterName = path
+	end.GwName = gwName
+	return nil
+}
+
+// Put is Add ignoring conflicts, the path added first wins.
+func (t *TreeNode) Put(path string, gwName string) {
+	_ = t.Add(path, gwName)
 }
 
+// Get returns the node ending the registered path matching path, or nil.
 func (t *TreeNode) Get(path string) *TreeNode {
-	strs := strings.Split(path, "/")
-	routerName := ""
-	for index, name := range strs {
-		if index == 0 {
-			continue
-		}
-		children := t.Children
-		isMatch := false
-		for _, node := range children {
-			if node.Name == name || node.Name == "*" || strings.Contains(node.Name, ":") {
-				isMatch = true
-				routerName += "/" + node.Name
-				node.RouterName = routerName
-				t = node
-				if index == len(strs)-1 {
-					return node
-				}
-				break
-			}
-		}
-		if !isMatch {
-			for _, node := range children {
-				if node.Name == "**" {
-					routerName += "/" + node.Name
-					node.RouterName = routerName
-					return node
-				}
-			}
-		}
+	var params radix.Params
+	node, ok := t.tree.Find(path, &params)
+	if !ok {
+		return nil
 	}
-	return nil
+	return node
 }
@@ -0,0 +1,43 @@
+package gateway
+
+import "testing"
+
+func TestTreeNode(t *testing.T) {
+	root := &TreeNode{Name: "/", Children: make([]*TreeNode, 0)}
+	root.Put("/order/**", "order")
+	root.Put("/goods/find/:id", "goods")
+	root.Put("/goods/find/:name", "other")
+
+	if len(root.Children) != 2 {
+		t.Fatalf("children = %d, want 2", len(root.Children))
+	}
+	tests := []struct {
+		path       string
+		gwName     string
+		routerName string
+	}{
+		{"/order/find", "order", "/order/**"},
+		{"/order/a/b", "order", "/order/**"},
+		{"/goods/find/1", "goods", "/goods/find/:id"},
+	}
+	for _, tt := range tests {
+		node := root.Get(tt.path)
+		if node == nil {
+			t.Fatalf("%s: not found", tt.path)
+		}
+		if node.GwName != tt.gwName || node.RouterName != tt.routerName || !node.IsEnd {
+			t.Errorf("%s: got %s %s, want %s %s", tt.path, node.GwName, node.RouterName, tt.gwName, tt.routerName)
+		}
+	}
+	goods := root.Children[1]
+	find := goods.Children[0]
+	if goods.GwName != "" || find.GwName != "" || root.Get("/goods/find/1") != find.Children[0] {
+		t.Errorf("intermediate nodes %q %q, Get is not the stored node", goods.GwName, find.GwName)
+	}
+	if node := root.Get("/user/find"); node != nil {
+		t.Errorf("/user/find: got %v, want nil", node)
+	}
+	if err := root.Add("/goods/find/:name", "other"); err == nil || len(find.Children) != 1 {
+		t.Errorf("conflicting path: got %v and %d children", err, len(find.Children))
+	}
+}
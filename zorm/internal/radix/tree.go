@@ -0,0 +1,332 @@
+package radix
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrDuplicateRoute = errors.New("duplicate route")
+	ErrRouteConflict  = errors.New("route conflict")
+)
+
+type Param struct {
+	Key   string
+	Value string
+}
+
+type Params []Param
+
+func (ps Params) Get(key string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+func (ps Params) ByName(key string) string {
+	value, _ := ps.Get(key)
+	return value
+}
+
+const (
+	wildcardKey = "*"
+	catchAllKey = "**"
+)
+
+// Tree is a compressed radix tree keyed by url path. Static segments share
+// prefixes, ":name" matches one segment, "*" matches one unnamed segment and
+// "**" matches the rest of the path. On lookup static segments win over
+// params and params win over wildcards. The tree is never modified by Find,
+// so it is safe for concurrent lookups once all routes are added.
+type Tree[T any] struct {
+	root      *node[T]
+	maxParams int
+}
+
+type node[T any] struct {
+	path     string
+	indices  string
+	children []*node[T]
+	param    *node[T]
+	wildcard *node[T]
+	catchAll *node[T]
+	key      string
+	pattern  string
+	hasValue bool
+	value    T
+}
+
+func (t *Tree[T]) Add(pattern string, value T) error {
+	if pattern == "" || pattern[0] != '/' {
+		return fmt.Errorf("path must begin with '/' in [%s]", pattern)
+	}
+	if t.root == nil {
+		t.root = &node[T]{}
+	}
+	n := t.root
+	rest := pattern
+	params := 0
+	for rest != "" {
+		start, end, err := nextDynamic(rest)
+		if err != nil {
+			return fmt.Errorf("%w in [%s]", err, pattern)
+		}
+		if start < 0 {
+			n = n.addStatic(rest)
+			break
+		}
+		n = n.addStatic(rest[:start])
+		segment := rest[start:end]
+		if segment == catchAllKey && end != len(rest) {
+			return fmt.Errorf("'**' must be the last segment in [%s]", pattern)
+		}
+		n, err = n.addDynamic(segment, pattern)
+		if err != nil {
+			return err
+		}
+		params++
+		rest = rest[end:]
+	}
+	if n.hasValue {
+		return fmt.Errorf("%w [%s]", ErrDuplicateRoute, pattern)
+	}
+	n.hasValue = true
+	n.value = value
+	if params > t.maxParams {
+		t.maxParams = params
+	}
+	return nil
+}
+
+// Find looks up path and appends the captured segments to ps. On a miss ps
+// is left as it was passed in.
+func (t *Tree[T]) Find(path string, ps *Params) (value T, ok bool) {
+	if t.root == nil {
+		return
+	}
+	n := t.root.lookup(path, ps)
+	if n == nil {
+		return
+	}
+	return n.value, true
+}
+
+// FindCaseInsensitive looks up path ignoring ASCII case and returns the path
+// spelled the way it was registered.
+func (t *Tree[T]) FindCaseInsensitive(path string) (string, bool) {
+	if t.root == nil {
+		return "", false
+	}
+	buf, ok := t.root.lookupFold(path, make([]byte, 0, len(path)))
+	return string(buf), ok
+}
+
+// MaxParams is the largest number of params a single route captures, it can
+// be used to size a reusable Params buffer.
+func (t *Tree[T]) MaxParams() int {
+	return t.maxParams
+}
+
+func nextDynamic(path string) (int, int, error) {
+	for i := 0; i < len(path); i++ {
+		if path[i] != '/' {
+			continue
+		}
+		start := i + 1
+		end := strings.IndexByte(path[start:], '/')
+		if end < 0 {
+			end = len(path)
+		} else {
+			end += start
+		}
+		segment := path[start:end]
+		if strings.ContainsAny(segment, ":*") {
+			if segment == wildcardKey || segment == catchAllKey {
+				return start, end, nil
+			}
+			if segment[0] != ':' || len(segment) == 1 || strings.ContainsAny(segment[1:], ":*") {
+				return -1, -1, fmt.Errorf("invalid segment '%s'", segment)
+			}
+			return start, end, nil
+		}
+		i = end - 1
+	}
+	return -1, -1, nil
+}
+
+func (n *node[T]) addStatic(path string) *node[T] {
+	for path != "" {
+		index := strings.IndexByte(n.indices, path[0])
+		if index < 0 {
+			child := &node[T]{path: path}
+			n.indices += string(path[0])
+			n.children = append(n.children, child)
+			return child
+		}
+		child := n.children[index]
+		l := commonPrefix(path, child.path)
+		if l < len(child.path) {
+			tail := *child
+			tail.path = child.path[l:]
+			*child = node[T]{
+				path:     child.path[:l],
+				indices:  string(tail.path[0]),
+				children: []*node[T]{&tail},
+			}
+		}
+		path = path[l:]
+		n = child
+	}
+	return n
+}
+
+// addDynamic returns the child for a ":name", "*" or "**" segment. A level
+// holds one param name and not both "*" and "**", otherwise the match would
+// depend on registration order. A param next to "*" or "**" is fine, the
+// param is tried first.
+func (n *node[T]) addDynamic(segment, pattern string) (*node[T], error) {
+	var slot **node[T]
+	var conflict *node[T]
+	key := segment
+	switch segment {
+	case catchAllKey:
+		slot = &n.catchAll
+		conflict = n.wildcard
+	case wildcardKey:
+		slot = &n.wildcard
+		conflict = n.catchAll
+	default:
+		slot = &n.param
+		key = segment[1:]
+		if n.param != nil && n.param.key != key {
+			conflict = n.param
+		}
+	}
+	if conflict != nil {
+		return nil, fmt.Errorf("%w: '%s' in [%s] conflicts with '%s' in [%s]",
+			ErrRouteConflict, segment, pattern, conflict.segment(), conflict.pattern)
+	}
+	if *slot == nil {
+		*slot = &node[T]{key: key, pattern: pattern}
+	}
+	return *slot, nil
+}
+
+func (n *node[T]) segment() string {
+	if n.key == wildcardKey || n.key == catchAllKey {
+		return n.key
+	}
+	return ":" + n.key
+}
+
+func (n *node[T]) lookup(path string, ps *Params) *node[T] {
+	if path == "" {
+		if n.hasValue {
+			return n
+		}
+		if n.catchAll != nil && n.catchAll.hasValue {
+			*ps = append(*ps, Param{Key: catchAllKey})
+			return n.catchAll
+		}
+		return nil
+	}
+	if index := strings.IndexByte(n.indices, path[0]); index >= 0 {
+		child := n.children[index]
+		if strings.HasPrefix(path, child.path) {
+			if found := child.lookup(path[len(child.path):], ps); found != nil {
+				return found
+			}
+		}
+	}
+	end := strings.IndexByte(path, '/')
+	if end < 0 {
+		end = len(path)
+	}
+	if end > 0 {
+		for _, child := range [...]*node[T]{n.param, n.wildcard} {
+			if child == nil {
+				continue
+			}
+			size := len(*ps)
+			*ps = append(*ps, Param{Key: child.key, Value: path[:end]})
+			if found := child.lookup(path[end:], ps); found != nil {
+				return found
+			}
+			*ps = (*ps)[:size]
+		}
+	}
+	if n.catchAll != nil && n.catchAll.hasValue {
+		*ps = append(*ps, Param{Key: catchAllKey, Value: path})
+		return n.catchAll
+	}
+	return nil
+}
+
+func (n *node[T]) lookupFold(path string, buf []byte) ([]byte, bool) {
+	if path == "" {
+		if n.hasValue || n.catchAll != nil && n.catchAll.hasValue {
+			return buf, true
+		}
+		return buf, false
+	}
+	for _, child := range n.children {
+		if len(path) >= len(child.path) && equalFoldASCII(path[:len(child.path)], child.path) {
+			if found, ok := child.lookupFold(path[len(child.path):], append(buf, child.path...)); ok {
+				return found, true
+			}
+		}
+	}
+	end := strings.IndexByte(path, '/')
+	if end < 0 {
+		end = len(path)
+	}
+	if end > 0 {
+		for _, child := range [...]*node[T]{n.param, n.wildcard} {
+			if child == nil {
+				continue
+			}
+			if found, ok := child.lookupFold(path[end:], append(buf, path[:end]...)); ok {
+				return found, true
+			}
+		}
+	}
+	if n.catchAll != nil && n.catchAll.hasValue {
+		return append(buf, path...), true
+	}
+	return buf, false
+}
+
+func equalFoldASCII(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if ca == cb {
+			continue
+		}
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
+
+func commonPrefix(a, b string) int {
+	max := len(a)
+	if len(b) < max {
+		max = len(b)
+	}
+	i := 0
+	for i < max && a[i] == b[i] {
+		i++
+	}
+	return i
+}
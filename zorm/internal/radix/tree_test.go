@@ -0,0 +1,236 @@
+package radix
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	tree := &Tree[string]{}
+	routes := []string{
+		"/user/get/:id",
+		"/user/create/hello",
+		"/user/create/aaa",
+		"/order/get/aaa",
+		"/user/new",
+		"/user/:id",
+		"/user/:id/info",
+		"/users",
+		"/g/*/get",
+		"/static/**",
+		"/",
+	}
+	for _, route := range routes {
+		if err := tree.Add(route, route); err != nil {
+			t.Fatalf("add %s: %v", route, err)
+		}
+	}
+
+	tests := []struct {
+		path   string
+		route  string
+		params Params
+	}{
+		{"/", "/", nil},
+		{"/user/get/1", "/user/get/:id", Params{{"id", "1"}}},
+		{"/user/create/hello", "/user/create/hello", nil},
+		{"/user/create/aaa", "/user/create/aaa", nil},
+		{"/order/get/aaa", "/order/get/aaa", nil},
+		{"/user/new", "/user/new", nil},
+		{"/user/old", "/user/:id", Params{{"id", "old"}}},
+		{"/user/get", "/user/:id", Params{{"id", "get"}}},
+		{"/user/new/info", "/user/:id/info", Params{{"id", "new"}}},
+		{"/users", "/users", nil},
+		{"/g/abc/get", "/g/*/get", Params{{"*", "abc"}}},
+		{"/static/", "/static/**", Params{{"**", ""}}},
+		{"/static/css/main.css", "/static/**", Params{{"**", "css/main.css"}}},
+		{"/user", "", nil},
+		{"/user/", "", nil},
+		{"/order/get", "", nil},
+		{"/g/abc", "", nil},
+	}
+	for _, tt := range tests {
+		var ps Params
+		route, ok := tree.Find(tt.path, &ps)
+		if tt.route == "" {
+			if ok {
+				t.Errorf("%s: expected no match, got %s", tt.path, route)
+			}
+			continue
+		}
+		if !ok || route != tt.route {
+			t.Errorf("%s: got route %q, want %q", tt.path, route, tt.route)
+			continue
+		}
+		if len(ps) != len(tt.params) {
+			t.Errorf("%s: got params %v, want %v", tt.path, ps, tt.params)
+			continue
+		}
+		for i := range ps {
+			if ps[i] != tt.params[i] {
+				t.Errorf("%s: got params %v, want %v", tt.path, ps, tt.params)
+			}
+		}
+	}
+}
+
+func TestTreeAddErrors(t *testing.T) {
+	tree := &Tree[string]{}
+	if err := tree.Add("/a/:id", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Add("/a/:id", ""); !errors.Is(err, ErrDuplicateRoute) {
+		t.Errorf("expected duplicate route error, got %v", err)
+	}
+	for _, route := range []string{"a", "/a/**/b", "/a/:", "/a/b:c", "/a/x*"} {
+		if err := tree.Add(route, ""); err == nil {
+			t.Errorf("%s: expected error", route)
+		}
+	}
+}
+
+func TestTreeConflicts(t *testing.T) {
+	tests := []struct {
+		exist    string
+		conflict string
+	}{
+		{"/a/:id", "/a/:name"},
+		{"/a/:id/info", "/a/:name"},
+		{"/a/*", "/a/**"},
+		{"/a/**", "/a/*/b"},
+	}
+	for _, tt := range tests {
+		tree := &Tree[string]{}
+		if err := tree.Add(tt.exist, ""); err != nil {
+			t.Fatal(err)
+		}
+		err := tree.Add(tt.conflict, "")
+		if !errors.Is(err, ErrRouteConflict) {
+			t.Errorf("%s after %s: expected conflict, got %v", tt.conflict, tt.exist, err)
+		}
+	}
+
+	tree := &Tree[string]{}
+	for _, route := range []string{"/a/:id", "/a/:id/info", "/a/new", "/b/*", "/b/*/c", "/c/**", "/a/*", "/c/:id"} {
+		if err := tree.Add(route, ""); err != nil {
+			t.Errorf("%s: unexpected error %v", route, err)
+		}
+	}
+}
+
+func TestTreeBacktracking(t *testing.T) {
+	tree := &Tree[string]{}
+	for _, route := range []string{"/files/:name/info", "/files/*/raw", "/files/:name", "/docs/:name/info", "/docs/**"} {
+		if err := tree.Add(route, route); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		path   string
+		route  string
+		params Params
+	}{
+		{"/files/a", "/files/:name", Params{{"name", "a"}}},
+		{"/files/a/info", "/files/:name/info", Params{{"name", "a"}}},
+		{"/files/a/raw", "/files/*/raw", Params{{"*", "a"}}},
+		{"/files/a/other", "", nil},
+		{"/docs/a/info", "/docs/:name/info", Params{{"name", "a"}}},
+		{"/docs/a", "/docs/**", Params{{"**", "a"}}},
+		{"/docs/a/info/b", "/docs/**", Params{{"**", "a/info/b"}}},
+	}
+	for _, tt := range tests {
+		var ps Params
+		route, ok := tree.Find(tt.path, &ps)
+		if tt.route == "" {
+			if ok || len(ps) > 0 {
+				t.Errorf("%s: expected no match, got %q %v", tt.path, route, ps)
+			}
+			continue
+		}
+		if !ok || route != tt.route || len(ps) != len(tt.params) || len(ps) > 0 && ps[0] != tt.params[0] {
+			t.Errorf("%s: got %q %v, want %q %v", tt.path, route, ps, tt.route, tt.params)
+		}
+	}
+
+	for path, fixed := range map[string]string{
+		"/FILES/A/RAW":   "/files/A/raw",
+		"/Files/a/INFO":  "/files/a/info",
+		"/DOCS/a/info/b": "/docs/a/info/b",
+	} {
+		if got, ok := tree.FindCaseInsensitive(path); !ok || got != fixed {
+			t.Errorf("%s: got %q, want %q", path, got, fixed)
+		}
+	}
+}
+
+func TestTreeFindCaseInsensitive(t *testing.T) {
+	tree := &Tree[string]{}
+	for _, route := range []string{"/User/Info", "/user/get/:id", "/Static/**"} {
+		if err := tree.Add(route, route); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		path  string
+		fixed string
+	}{
+		{"/user/info", "/User/Info"},
+		{"/USER/INFO", "/User/Info"},
+		{"/USER/GET/AbC", "/user/get/AbC"},
+		{"/static/Css/A.css", "/Static/Css/A.css"},
+		{"/user/none", ""},
+	}
+	for _, tt := range tests {
+		fixed, ok := tree.FindCaseInsensitive(tt.path)
+		if tt.fixed == "" {
+			if ok {
+				t.Errorf("%s: expected no match, got %s", tt.path, fixed)
+			}
+			continue
+		}
+		if !ok || fixed != tt.fixed {
+			t.Errorf("%s: got %q, want %q", tt.path, fixed, tt.fixed)
+		}
+	}
+}
+
+func TestTreeConcurrentFind(t *testing.T) {
+	tree := &Tree[string]{}
+	_ = tree.Add("/user/:id", "param")
+	_ = tree.Add("/user/new", "static")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ps := make(Params, 0, tree.MaxParams())
+			for j := 0; j < 1000; j++ {
+				ps = ps[:0]
+				if v, _ := tree.Find("/user/new", &ps); v != "static" {
+					t.Errorf("got %s, want static", v)
+					return
+				}
+				ps = ps[:0]
+				if v, _ := tree.Find("/user/1", &ps); v != "param" || ps.ByName("id") != "1" {
+					t.Errorf("got %s %v, want param", v, ps)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTreeFindAllocs(t *testing.T) {
+	tree := &Tree[string]{}
+	_ = tree.Add("/user/:id/info", "")
+	ps := make(Params, 0, tree.MaxParams())
+	allocs := testing.AllocsPerRun(100, func() {
+		ps = ps[:0]
+		tree.Find("/user/100/info", &ps)
+	})
+	if allocs != 0 {
+		t.Errorf("Find allocated %v times", allocs)
+	}
+}
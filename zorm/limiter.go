@@ -2,19 +2,37 @@ package zorm
 
 import (
 	"context"
+	"github.com/caixr9527/zorm/config"
 	"golang.org/x/time/rate"
 	"net/http"
 	"time"
 )
 
 func Limiter(limit, cap int) MiddlewareFunc {
-	li := rate.NewLimiter(rate.Limit(limit), cap)
+	return limiter(rate.NewLimiter(rate.Limit(limit), cap))
+}
+
+// LimiterFromConfig is Limiter with the limit and cap of the [limiter]
+// config section, a reload of the section changes them in place.
+func LimiterFromConfig() MiddlewareFunc {
+	conf := config.Current().Zorm().Limiter
+	li := rate.NewLimiter(rate.Limit(conf.Limit), conf.Cap)
+	config.OnChange("limiter", func(old, new *config.Config) {
+		conf := new.Zorm().Limiter
+		li.SetLimit(rate.Limit(conf.Limit))
+		li.SetBurst(conf.Cap)
+	})
+	return limiter(li)
+}
+
+func limiter(li *rate.Limiter) MiddlewareFunc {
 	return func(next HandlerFunc) HandlerFunc {
 		return func(ctx *Context) {
-			context, cancel := context.WithTimeout(context.Background(), time.Duration(1)*time.Second)
+			context, cancel := context.WithTimeout(ctx, time.Duration(1)*time.Second)
 			defer cancel()
 			err := li.WaitN(context, 1)
 			if err != nil {
+				limiterRejected.With(ctx.FullPath()).Inc()
 				ctx.String(http.StatusForbidden, "被限流了")
 				return
 			}
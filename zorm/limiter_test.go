@@ -0,0 +1,56 @@
+package zorm
+
+import (
+	"bytes"
+	"github.com/caixr9527/zorm/config"
+	"github.com/caixr9527/zorm/log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.toml")
+	write := func(content string) {
+		content = "[log]\npath = " + strconv.Quote(dir) + "\n" + content
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("level = \"error\"\n[limiter]\nlimit = 0\ncap = 0\n")
+	if err := config.Init(config.Options{Files: []string{file}, DisableEnv: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer config.Init(config.Options{Optional: true, DisableEnv: true})
+
+	engine := Default()
+	var buf bytes.Buffer
+	engine.Logger.Outs = []*log.LoggerWriter{{Level: -1, Out: &buf}}
+	engine.Group("user").Get("/info", testHandler, LimiterFromConfig())
+	engine.Logger.WithFields(log.Fields{"k": "v"}).Info("hidden")
+	serve := func() int {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/info", nil))
+		return w.Code
+	}
+	if code := serve(); code != http.StatusForbidden {
+		t.Errorf("limit 0: got %d", code)
+	}
+
+	write("level = \"info\"\n[limiter]\nlimit = 100\ncap = 10\n")
+	if err := config.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(); code != http.StatusOK {
+		t.Errorf("after reload: got %d", code)
+	}
+	engine.Logger.WithFields(log.Fields{"k": "v"}).Info("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("log level not reloaded: %q", out)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 )
 
@@ -31,6 +30,8 @@ type LogFormatterParams struct {
 	Request        *http.Request
 	TimeStamp      time.Time
 	StatusCode     int
+	RequestID      string
+	BodySize       int
 	Latency        time.Duration
 	ClientIp       net.IP
 	Method         string
@@ -58,16 +59,20 @@ var defaultFormatter = func(params *LogFormatterParams) string {
 	if params.Latency > time.Minute {
 		params.Latency = params.Latency.Truncate(time.Second)
 	}
+	requestID := ""
+	if params.RequestID != "" {
+		requestID = " | " + params.RequestID
+	}
 	if params.IsDisplayColor {
-		return fmt.Sprintf("[zorm] %v |%s %3d %s| %13v | %15v | %-7s %#v\n",
+		return fmt.Sprintf("[zorm] %v |%s %3d %s| %13v | %15v | %-7s %#v%s\n",
 			params.TimeStamp.Format("2006/01/02 - 15:04:05"),
 			statusCodeColor, params.StatusCode, reset,
-			params.Latency, params.ClientIp, params.Method, params.Path)
+			params.Latency, params.ClientIp, params.Method, params.Path, requestID)
 	}
-	return fmt.Sprintf("[zorm] %v | %3d | %13v | %15v | %-7s %#v\n",
+	return fmt.Sprintf("[zorm] %v | %3d | %13v | %15v | %-7s %#v%s\n",
 		params.TimeStamp.Format("2006/01/02 - 15:04:05"),
 		params.StatusCode,
-		params.Latency, params.ClientIp, params.Method, params.Path)
+		params.Latency, params.ClientIp, params.Method, params.Path, requestID)
 }
 
 func LoggingWithConfig(conf LoggingConfig, next HandlerFunc) HandlerFunc {
@@ -96,10 +101,9 @@ func LoggingWithConfig(conf LoggingConfig, next HandlerFunc) HandlerFunc {
 
 		stop := time.Now()
 		latency := stop.Sub(start)
-		ip, _, _ := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
-		clientIp := net.ParseIP(ip)
+		clientIp := net.ParseIP(ctx.ClientIP())
 		method := r.Method
-		statusCode := ctx.StatusCode
+		statusCode := ctx.W.Status()
 
 		if raw != "" {
 			path = path + "?" + raw
@@ -110,6 +114,8 @@ func LoggingWithConfig(conf LoggingConfig, next HandlerFunc) HandlerFunc {
 		param.ClientIp = clientIp
 		param.Method = method
 		param.StatusCode = statusCode
+		param.BodySize = ctx.W.Size()
+		param.RequestID = ctx.RequestID()
 		param.Path = path
 
 		_, _ = fmt.Fprint(out, formatter(param))
@@ -11,17 +11,19 @@ type JsonFormatter struct {
 }
 
 func (f *JsonFormatter) Format(param *LoggingFormatParam) string {
-	if param.LoggerFields == nil {
-		param.LoggerFields = make(Fields)
+	// the fields belong to the Logger, which may be logging concurrently
+	fields := make(Fields, len(param.LoggerFields)+3)
+	for k, v := range param.LoggerFields {
+		fields[k] = v
 	}
 	now := time.Now()
 	if f.TimeDisplay {
-		param.LoggerFields["log_time"] = now.Format("2006/01/02 - 15:04:05")
+		fields["log_time"] = now.Format("2006/01/02 - 15:04:05")
 
 	}
-	param.LoggerFields["msg"] = param.Msg
-	param.LoggerFields["level"] = param.Level.Level()
-	marshal, err := json.Marshal(param.LoggerFields)
+	fields["msg"] = param.Msg
+	fields["level"] = param.Level.Level()
+	marshal, err := json.Marshal(fields)
 	if err != nil {
 		panic(err)
 	}
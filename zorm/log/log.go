@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -36,6 +37,9 @@ type Logger struct {
 	LoggerFields Fields
 	logPath      string
 	LogFileSize  int64
+	// dynLevel is set by SetLevel and shared with the WithFields loggers,
+	// -1 means Level is used.
+	dynLevel *atomic.Int64
 }
 
 type LoggerWriter struct {
@@ -60,6 +64,16 @@ type LoggerFormatter struct {
 	LoggerFields Fields
 }
 
+// ParseLevel returns the level named s, as written by Level ignoring case.
+func ParseLevel(s string) (LoggerLevel, error) {
+	for _, level := range []LoggerLevel{Debug, Info, Error} {
+		if strings.EqualFold(s, level.Level()) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
 func (l LoggerLevel) Level() string {
 	switch l {
 	case Debug:
@@ -74,7 +88,9 @@ func (l LoggerLevel) Level() string {
 }
 
 func New() *Logger {
-	return &Logger{}
+	l := &Logger{dynLevel: new(atomic.Int64)}
+	l.dynLevel.Store(-1)
+	return l
 }
 
 func Default() *Logger {
@@ -100,8 +116,29 @@ func (l *Logger) Debug(msg any) {
 func (l *Logger) Error(msg any) {
 	l.Print(msg, Error)
 }
+
+// SetLevel changes the level of l and of the loggers created from it with
+// WithFields. Unlike setting Level it is safe while logging, e.g. from a
+// config.OnChange callback.
+func (l *Logger) SetLevel(level LoggerLevel) {
+	if l.dynLevel == nil {
+		l.Level = level
+		return
+	}
+	l.dynLevel.Store(int64(level))
+}
+
+func (l *Logger) level() LoggerLevel {
+	if l.dynLevel != nil {
+		if level := l.dynLevel.Load(); level >= 0 {
+			return LoggerLevel(level)
+		}
+	}
+	return l.Level
+}
+
 func (l *Logger) Print(msg any, level LoggerLevel) {
-	if l.Level > level {
+	if l.level() > level {
 		return
 	}
 
@@ -125,12 +162,23 @@ func (l *Logger) Print(msg any, level LoggerLevel) {
 	}
 }
 
+// WithFields returns a Logger adding fields to those of l on every line.
 func (l *Logger) WithFields(fields Fields) *Logger {
+	merged := make(Fields, len(l.LoggerFields)+len(fields))
+	for k, v := range l.LoggerFields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
 	return &Logger{
 		Formatter:    l.Formatter,
 		Outs:         l.Outs,
 		Level:        l.Level,
-		LoggerFields: fields,
+		LoggerFields: merged,
+		logPath:      l.logPath,
+		LogFileSize:  l.LogFileSize,
+		dynLevel:     l.dynLevel,
 	}
 }
 
@@ -155,8 +203,8 @@ func (l *Logger) SetLogPath(logPath string) {
 }
 
 func (l *Logger) checkFileSize(writer *LoggerWriter) {
-	logFile := writer.Out.(*os.File)
-	if logFile != nil {
+	logFile, ok := writer.Out.(*os.File)
+	if ok && logFile != nil {
 		stat, err := logFile.Stat()
 		if err != nil {
 			log.Println(err)
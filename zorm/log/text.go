@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -12,10 +13,15 @@ type TextFormatter struct {
 func (f *TextFormatter) Format(param *LoggingFormatParam) string {
 	now := time.Now()
 	fieldsString := ""
-	if param.LoggerFields != nil {
+	if len(param.LoggerFields) > 0 {
+		keys := make([]string, 0, len(param.LoggerFields))
+		for key := range param.LoggerFields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
 		var sb strings.Builder
-		for key, val := range param.LoggerFields {
-			fmt.Fprintf(&sb, "%s=%v%s", key, val, ",")
+		for _, key := range keys {
+			fmt.Fprintf(&sb, "%s=%v%s", key, param.LoggerFields[key], ",")
 		}
 		fieldsString = sb.String()[0 : sb.Len()-1]
 
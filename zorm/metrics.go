@@ -0,0 +1,63 @@
+package zorm
+
+import (
+	"github.com/caixr9527/zorm/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec("zorm_http_requests_total",
+		"HTTP requests by method, route pattern and status.", "method", "route", "status")
+	httpDuration = metrics.NewHistogramVec("zorm_http_request_duration_seconds",
+		"Latency of HTTP requests by method, route pattern and status.", nil, "method", "route", "status")
+	limiterRejected = metrics.NewCounterVec("zorm_limiter_rejected_total",
+		"Requests rejected by the Limiter middleware by route pattern.", "route")
+)
+
+func init() {
+	metrics.MustRegister(httpRequests, httpDuration, limiterRejected)
+}
+
+// Metrics counts requests and observes their latency in
+// metrics.DefaultRegistry. The route label is the pattern, e.g. /user/:id,
+// so it stays bounded; it is empty for requests matching no route. A
+// panicking handler is counted as a 500 and the panic is passed on.
+func Metrics(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		start := time.Now()
+		defer func() {
+			code := ctx.W.Status()
+			err := recover()
+			if err != nil {
+				code = http.StatusInternalServerError
+			}
+			status := strconv.Itoa(code)
+			httpRequests.With(ctx.R.Method, ctx.FullPath(), status).Inc()
+			httpDuration.With(ctx.R.Method, ctx.FullPath(), status).ObserveSince(start)
+			if err != nil {
+				panic(err)
+			}
+		}()
+		next(ctx)
+	}
+}
+
+// MetricsHandler writes the metrics of registry, metrics.DefaultRegistry
+// when nil, in the Prometheus text format.
+func MetricsHandler(registry *metrics.Registry) HandlerFunc {
+	if registry == nil {
+		registry = metrics.DefaultRegistry
+	}
+	return func(ctx *Context) {
+		ctx.W.Header().Set("Content-Type", metrics.ContentType)
+		ctx.W.WriteHeader(http.StatusOK)
+		registry.WriteTo(ctx.W)
+	}
+}
+
+// Metrics serves metrics.DefaultRegistry on GET path, e.g. /metrics.
+func (e *Engine) Metrics(path string, middlewareFunc ...MiddlewareFunc) {
+	e.root().Get(path, MetricsHandler(nil), middlewareFunc...)
+}
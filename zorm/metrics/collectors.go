@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/caixr9527/zorm/breaker"
+	"github.com/caixr9527/zorm/zpool"
+)
+
+type poolCollector struct {
+	name string
+	pool *zpool.Pool
+}
+
+// NewPoolCollector exposes the running and free workers of pool as
+// zorm_pool_workers, pools are told apart by name.
+func NewPoolCollector(name string, pool *zpool.Pool) Collector {
+	return &poolCollector{name: name, pool: pool}
+}
+
+func (c *poolCollector) Desc() Desc {
+	return Desc{Name: "zorm_pool_workers", Help: "Workers of a zpool.Pool by state.", Type: GaugeType}
+}
+
+func (c *poolCollector) Collect(emit func(Sample)) {
+	emit(Sample{Labels: []Label{{"pool", c.name}, {"state", "running"}}, Value: float64(c.pool.Running())})
+	emit(Sample{Labels: []Label{{"pool", c.name}, {"state", "free"}}, Value: float64(c.pool.Free())})
+}
+
+type breakerCollector struct {
+	cb *breaker.CircuitBreaker
+}
+
+// NewBreakerCollector exposes the state of cb as zorm_breaker_state,
+// labelled by the Settings.Name of the breaker.
+func NewBreakerCollector(cb *breaker.CircuitBreaker) Collector {
+	return &breakerCollector{cb: cb}
+}
+
+func (c *breakerCollector) Desc() Desc {
+	return Desc{Name: "zorm_breaker_state", Help: "State of a circuit breaker: 0 closed, 1 half-open, 2 open.", Type: GaugeType}
+}
+
+func (c *breakerCollector) Collect(emit func(Sample)) {
+	emit(Sample{Labels: []Label{{"breaker", c.cb.Name()}}, Value: float64(c.cb.State())})
+}
@@ -0,0 +1,339 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefBuckets are the default histogram buckets, in seconds, for the
+// latency of network calls.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func newDesc(name, help string, typ Type) Desc {
+	if !metricNameRe.MatchString(name) {
+		panic("metrics: invalid metric name " + name)
+	}
+	return Desc{Name: name, Help: help, Type: typ}
+}
+
+func constLabels(labels Labels) []Label {
+	list := make([]Label, 0, len(labels))
+	for name, value := range labels {
+		if !labelNameRe.MatchString(name) {
+			panic("metrics: invalid label name " + name)
+		}
+		list = append(list, Label{Name: name, Value: value})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Counter is a value that only goes up.
+type Counter struct {
+	desc   Desc
+	labels []Label
+	value  atomicFloat
+}
+
+func NewCounter(name, help string) *Counter {
+	return &Counter{desc: newDesc(name, help, CounterType)}
+}
+
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add adds v, which must not be negative.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.value.add(v)
+}
+
+func (c *Counter) Value() float64 {
+	return c.value.load()
+}
+
+func (c *Counter) Desc() Desc {
+	return c.desc
+}
+
+func (c *Counter) Collect(emit func(Sample)) {
+	emit(Sample{Labels: c.labels, Value: c.value.load()})
+}
+
+// Gauge is a value that goes up and down.
+type Gauge struct {
+	desc   Desc
+	labels []Label
+	value  atomicFloat
+}
+
+func NewGauge(name, help string) *Gauge {
+	return &Gauge{desc: newDesc(name, help, GaugeType)}
+}
+
+func (g *Gauge) Set(v float64) {
+	g.value.set(v)
+}
+
+func (g *Gauge) Add(v float64) {
+	g.value.add(v)
+}
+
+func (g *Gauge) Inc() {
+	g.value.add(1)
+}
+
+func (g *Gauge) Dec() {
+	g.value.add(-1)
+}
+
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
+
+func (g *Gauge) Desc() Desc {
+	return g.desc
+}
+
+func (g *Gauge) Collect(emit func(Sample)) {
+	emit(Sample{Labels: g.labels, Value: g.value.load()})
+}
+
+// GaugeFunc is a gauge whose value is read from f when collected, e.g. the
+// running workers of a pool.
+type GaugeFunc struct {
+	desc   Desc
+	labels []Label
+	f      func() float64
+}
+
+func NewGaugeFunc(name, help string, labels Labels, f func() float64) *GaugeFunc {
+	return &GaugeFunc{desc: newDesc(name, help, GaugeType), labels: constLabels(labels), f: f}
+}
+
+func (g *GaugeFunc) Desc() Desc {
+	return g.desc
+}
+
+func (g *GaugeFunc) Collect(emit func(Sample)) {
+	emit(Sample{Labels: g.labels, Value: g.f()})
+}
+
+// Histogram counts observations in buckets of upper bounds.
+type Histogram struct {
+	desc    Desc
+	labels  []Label
+	buckets []float64
+	// counts[i] counts the observations in (buckets[i-1], buckets[i]], the
+	// last one those above every bucket
+	counts []atomic.Uint64
+	sum    atomicFloat
+}
+
+// NewHistogram returns a histogram with the given increasing buckets,
+// DefBuckets when empty.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return newHistogram(newDesc(name, help, HistogramType), nil, checkBuckets(buckets))
+}
+
+func newHistogram(desc Desc, labels []Label, buckets []float64) *Histogram {
+	return &Histogram{
+		desc:    desc,
+		labels:  labels,
+		buckets: buckets,
+		counts:  make([]atomic.Uint64, len(buckets)+1),
+	}
+}
+
+func checkBuckets(buckets []float64) []float64 {
+	if len(buckets) == 0 {
+		return DefBuckets
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic("metrics: histogram buckets must be increasing")
+		}
+	}
+	if math.IsInf(buckets[len(buckets)-1], 1) {
+		// the +Inf bucket is always written
+		buckets = buckets[:len(buckets)-1]
+	}
+	return buckets
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.counts[sort.SearchFloat64s(h.buckets, v)].Add(1)
+	h.sum.add(v)
+}
+
+// ObserveSince observes the seconds elapsed since start.
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+func (h *Histogram) Desc() Desc {
+	return h.desc
+}
+
+func (h *Histogram) Collect(emit func(Sample)) {
+	var count uint64
+	for i, upper := range h.buckets {
+		count += h.counts[i].Load()
+		emit(Sample{Suffix: "_bucket", Labels: withLabel(h.labels, "le", formatFloat(upper)), Value: float64(count)})
+	}
+	count += h.counts[len(h.buckets)].Load()
+	emit(Sample{Suffix: "_bucket", Labels: withLabel(h.labels, "le", "+Inf"), Value: float64(count)})
+	emit(Sample{Suffix: "_sum", Labels: h.labels, Value: h.sum.load()})
+	emit(Sample{Suffix: "_count", Labels: h.labels, Value: float64(count)})
+}
+
+func withLabel(labels []Label, name, value string) []Label {
+	list := make([]Label, len(labels), len(labels)+1)
+	copy(list, labels)
+	return append(list, Label{Name: name, Value: value})
+}
+
+// vec holds the children of a metric for each combination of label values.
+type vec[T Collector] struct {
+	desc       Desc
+	labelNames []string
+	newChild   func(labels []Label) T
+	mu         sync.RWMutex
+	children   map[string]T
+}
+
+func newVec[T Collector](desc Desc, labelNames []string, newChild func(labels []Label) T) *vec[T] {
+	for _, name := range labelNames {
+		if !labelNameRe.MatchString(name) || name == "le" {
+			panic("metrics: invalid label name " + name)
+		}
+	}
+	return &vec[T]{desc: desc, labelNames: labelNames, newChild: newChild, children: make(map[string]T)}
+}
+
+func (v *vec[T]) with(values []string) T {
+	if len(values) != len(v.labelNames) {
+		panic("metrics: " + v.desc.Name + " wants labels " + strings.Join(v.labelNames, ", "))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.RLock()
+	child, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return child
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if child, ok = v.children[key]; ok {
+		return child
+	}
+	labels := make([]Label, len(values))
+	for i, value := range values {
+		labels[i] = Label{Name: v.labelNames[i], Value: value}
+	}
+	child = v.newChild(labels)
+	v.children[key] = child
+	return child
+}
+
+func (v *vec[T]) Desc() Desc {
+	return v.desc
+}
+
+func (v *vec[T]) Collect(emit func(Sample)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	children := make([]T, len(keys))
+	for i, key := range keys {
+		children[i] = v.children[key]
+	}
+	v.mu.RUnlock()
+	for _, child := range children {
+		child.Collect(emit)
+	}
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	*vec[*Counter]
+}
+
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	desc := newDesc(name, help, CounterType)
+	return &CounterVec{newVec(desc, labelNames, func(labels []Label) *Counter {
+		return &Counter{desc: desc, labels: labels}
+	})}
+}
+
+// With returns the counter of the label values, given in the order of
+// the label names.
+func (v *CounterVec) With(labelValues ...string) *Counter {
+	return v.with(labelValues)
+}
+
+// GaugeVec is a gauge partitioned by labels.
+type GaugeVec struct {
+	*vec[*Gauge]
+}
+
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	desc := newDesc(name, help, GaugeType)
+	return &GaugeVec{newVec(desc, labelNames, func(labels []Label) *Gauge {
+		return &Gauge{desc: desc, labels: labels}
+	})}
+}
+
+func (v *GaugeVec) With(labelValues ...string) *Gauge {
+	return v.with(labelValues)
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct {
+	*vec[*Histogram]
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	desc := newDesc(name, help, HistogramType)
+	buckets = checkBuckets(buckets)
+	return &HistogramVec{newVec(desc, labelNames, func(labels []Label) *Histogram {
+		return newHistogram(desc, labels, buckets)
+	})}
+}
+
+func (v *HistogramVec) With(labelValues ...string) *Histogram {
+	return v.with(labelValues)
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/caixr9527/zorm/breaker"
+	"github.com/caixr9527/zorm/zpool"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+	requests := NewCounterVec("http_requests_total", "Requests.\nBy code.", "code", "path")
+	requests.With("200", `/a"b\c`).Add(2)
+	requests.With("500", "/").Inc()
+	up := NewGauge("up", "Up.")
+	up.Set(1)
+	up.Dec()
+	up.Add(0.5)
+	latency := NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1})
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		latency.Observe(v)
+	}
+	r.MustRegister(up, requests, latency, NewGaugeFunc("build_info", "Build.", Labels{"version": "1.0"}, func() float64 { return 1 }))
+
+	var sb strings.Builder
+	if _, err := r.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP build_info Build.
+# TYPE build_info gauge
+build_info{version="1.0"} 1
+# HELP http_requests_total Requests.\nBy code.
+# TYPE http_requests_total counter
+http_requests_total{code="200",path="/a\"b\\c"} 2
+http_requests_total{code="500",path="/"} 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 2
+latency_seconds_bucket{le="1"} 3
+latency_seconds_bucket{le="+Inf"} 4
+latency_seconds_sum 3.65
+latency_seconds_count 4
+# HELP up Up.
+# TYPE up gauge
+up 0.5
+`
+	if sb.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Header().Get("Content-Type") != ContentType || w.Body.String() != want {
+		t.Errorf("handler %q", w.Header().Get("Content-Type"))
+	}
+}
+
+type badCollector struct{}
+
+func (badCollector) Desc() Desc                { return Desc{Name: "bad-name", Type: GaugeType} }
+func (badCollector) Collect(emit func(Sample)) {}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounter("calls_total", "Calls.")
+	if err := r.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(c); err == nil {
+		t.Error("registered twice")
+	}
+	if err := r.Register(NewGauge("calls_total", "Calls.")); err == nil {
+		t.Error("registered with another type")
+	}
+	// same family from another collector
+	if err := r.Register(NewCounter("calls_total", "Calls.")); err != nil {
+		t.Error(err)
+	}
+	if !r.Unregister(c) || r.Unregister(c) {
+		t.Error("unregister")
+	}
+	if err := r.Register(badCollector{}); err == nil {
+		t.Error("invalid name")
+	}
+}
+
+func TestPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"negative counter", func() { NewCounter("c", "").Add(-1) }},
+		{"label count", func() { NewCounterVec("c", "", "a", "b").With("x") }},
+		{"le label", func() { NewHistogramVec("h", "", nil, "le") }},
+		{"buckets", func() { NewHistogram("h", "", []float64{1, 1}) }},
+		{"metric name", func() { NewGauge("1g", "") }},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", tt.name)
+				}
+			}()
+			tt.f()
+		}()
+	}
+}
+
+func TestCollectors(t *testing.T) {
+	pool, err := zpool.NewPool(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Release()
+	cb := breaker.NewCircuitBreaker(breaker.Settings{
+		Name:        "goods",
+		ReadyToTrip: func(counts breaker.Counts) bool { return counts.ConsecutiveFailures > 0 },
+	})
+	r := NewRegistry()
+	r.MustRegister(NewPoolCollector("orders", pool), NewBreakerCollector(cb))
+	cb.Execute(func() (any, error) { return nil, errors.New("down") })
+
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	for _, line := range []string{
+		`zorm_breaker_state{breaker="goods"} 2`,
+		`zorm_pool_workers{pool="orders",state="running"} 0`,
+		`zorm_pool_workers{pool="orders",state="free"} 4`,
+	} {
+		if !strings.Contains(sb.String(), line+"\n") {
+			t.Errorf("missing %s in\n%s", line, sb.String())
+		}
+	}
+}
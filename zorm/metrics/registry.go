@@ -0,0 +1,239 @@
+// Package metrics keeps counters, gauges and histograms and exposes them
+// in the Prometheus text format.
+//
+//	requests := metrics.NewCounterVec("orders_total", "Orders placed.", "status")
+//	metrics.MustRegister(requests)
+//	requests.With("paid").Inc()
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Type string
+
+const (
+	CounterType   Type = "counter"
+	GaugeType     Type = "gauge"
+	HistogramType Type = "histogram"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	metricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRe  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Desc describes a metric family.
+type Desc struct {
+	Name string
+	Help string
+	Type Type
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Labels are constant labels of a metric.
+type Labels map[string]string
+
+// Sample is one line of a metric family.
+type Sample struct {
+	// Suffix is appended to the family name, e.g. "_bucket".
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Collector is a metric family, or part of one, that a Registry exposes.
+type Collector interface {
+	Desc() Desc
+	// Collect calls emit for each sample of the collector.
+	Collect(emit func(Sample))
+}
+
+type family struct {
+	desc       Desc
+	collectors []Collector
+}
+
+// Registry holds the collectors to expose. Collectors with the same name
+// and the same help and type are written as one family.
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// DefaultRegistry holds the metrics of zorm and its packages.
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) Register(c Collector) error {
+	desc := c.Desc()
+	if !metricNameRe.MatchString(desc.Name) {
+		return fmt.Errorf("metrics: invalid metric name %q", desc.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.families[desc.Name]
+	if !ok {
+		r.families[desc.Name] = &family{desc: desc, collectors: []Collector{c}}
+		return nil
+	}
+	if f.desc != desc {
+		return fmt.Errorf("metrics: %s already registered as %s %q", desc.Name, f.desc.Type, f.desc.Help)
+	}
+	for _, registered := range f.collectors {
+		if registered == c {
+			return errors.New("metrics: " + desc.Name + " already registered")
+		}
+	}
+	f.collectors = append(f.collectors, c)
+	return nil
+}
+
+// MustRegister registers the collectors, it panics on error.
+func (r *Registry) MustRegister(cs ...Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister removes c, it reports whether c was registered.
+func (r *Registry) Unregister(c Collector) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := c.Desc().Name
+	f, ok := r.families[name]
+	if !ok {
+		return false
+	}
+	for i, registered := range f.collectors {
+		if registered == c {
+			f.collectors = append(f.collectors[:i], f.collectors[i+1:]...)
+			if len(f.collectors) == 0 {
+				delete(r.families, name)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTo writes every family in the text exposition format, sorted by
+// name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, &family{desc: f.desc, collectors: append([]Collector(nil), f.collectors...)})
+	}
+	r.mu.RUnlock()
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].desc.Name < families[j].desc.Name
+	})
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.desc.Name, escape(f.desc.Help, false))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.desc.Name, f.desc.Type)
+		for _, c := range f.collectors {
+			c.Collect(func(s Sample) {
+				writeSample(bw, f.desc.Name, s)
+			})
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP writes the metrics, so the registry can be mounted as the
+// handler of /metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteTo(w)
+}
+
+func Register(c Collector) error {
+	return DefaultRegistry.Register(c)
+}
+
+func MustRegister(cs ...Collector) {
+	DefaultRegistry.MustRegister(cs...)
+}
+
+func writeSample(w *bufio.Writer, name string, s Sample) {
+	w.WriteString(name)
+	w.WriteString(s.Suffix)
+	if len(s.Labels) > 0 {
+		w.WriteByte('{')
+		for i, l := range s.Labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l.Name)
+			w.WriteString(`="`)
+			w.WriteString(escape(l.Value, true))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(s.Value))
+	w.WriteByte('\n')
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	valueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escape(s string, quoted bool) string {
+	if quoted {
+		return valueEscaper.Replace(s)
+	}
+	return helpEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
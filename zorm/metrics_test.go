@@ -0,0 +1,83 @@
+package zorm
+
+import (
+	"errors"
+	zormlog "github.com/caixr9527/zorm/log"
+	"github.com/caixr9527/zorm/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	engine := New()
+	engine.Use(Metrics)
+	engine.Group("metric").Get("/user/:id", func(ctx *Context) {
+		ctx.String(http.StatusCreated, "ok")
+	})
+	engine.Metrics("/metrics")
+	engine.Metrics("/stats")
+	if len(engine.routerGroups) != 2 {
+		t.Errorf("got %d groups", len(engine.routerGroups))
+	}
+	for _, path := range []string{"/metric/user/1", "/metric/user/2", "/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if got := httpRequests.With(http.MethodGet, "/metric/user/:id", "201").Value(); got != 2 {
+		t.Errorf("requests %v", got)
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != metrics.ContentType {
+		t.Fatalf("got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	for _, line := range []string{
+		`zorm_http_requests_total{method="GET",route="/metric/user/:id",status="201"} 2`,
+		`zorm_http_requests_total{method="GET",route="",status="404"} 1`,
+		`zorm_http_request_duration_seconds_count{method="GET",route="/metric/user/:id",status="201"} 2`,
+		`# TYPE zorm_limiter_rejected_total counter`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %s", line)
+		}
+	}
+}
+
+func TestMetricsPanic(t *testing.T) {
+	engine := New()
+	engine.Logger = zormlog.Default()
+	engine.Logger.Outs = nil
+	engine.Use(Recovery)
+	engine.Use(Metrics)
+	engine.Group("metric").Get("/panic", func(ctx *Context) {
+		panic(errors.New("boom"))
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metric/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got %d", w.Code)
+	}
+	if got := httpRequests.With(http.MethodGet, "/metric/panic", "500").Value(); got != 1 {
+		t.Errorf("requests %v", got)
+	}
+
+	bare := New()
+	bare.Use(Metrics)
+	bare.Group("metric").Get("/bare", func(ctx *Context) {
+		panic(errors.New("boom"))
+	})
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was swallowed")
+			}
+		}()
+		bare.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metric/bare", nil))
+	}()
+	if got := httpRequests.With(http.MethodGet, "/metric/bare", "500").Value(); got != 1 {
+		t.Errorf("bare requests %v", got)
+	}
+}
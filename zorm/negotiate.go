@@ -0,0 +1,138 @@
+package zorm
+
+import (
+	"errors"
+	"github.com/caixr9527/zorm/binding"
+	"github.com/caixr9527/zorm/render"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrNotAcceptable = errors.New("the accepted formats are not offered by the server")
+
+// Negotiate lists the formats a handler can answer with, in the order it
+// prefers them. The data of a format falls back to Data when it is nil.
+type Negotiate struct {
+	Offered      []string
+	HTMLName     string
+	HTMLData     any
+	JSONData     any
+	XMLData      any
+	YAMLData     any
+	TOMLData     any
+	ProtoBufData any
+	MsgPackData  any
+	Data         any
+}
+
+// Negotiate renders the offered format the Accept header ranks highest, it
+// answers 406 and returns ErrNotAcceptable when none is acceptable.
+func (c *Context) Negotiate(code int, config Negotiate) error {
+	pick := func(data any) any {
+		if data == nil {
+			return config.Data
+		}
+		return data
+	}
+	switch c.NegotiateFormat(config.Offered...) {
+	case binding.MIMEJSON:
+		return c.JSON(code, pick(config.JSONData))
+	case binding.MIMEHTML:
+		data := pick(config.HTMLData)
+		if config.HTMLName == "" {
+			s, _ := data.(string)
+			return c.HTML(code, s)
+		}
+		return c.Render(code, &render.HTML{
+			Data:       data,
+			IsTemplate: true,
+			Template:   c.engine.HTMLRender.Template,
+			Name:       config.HTMLName,
+		})
+	case binding.MIMEXML, binding.MIMEXML2:
+		return c.XML(code, pick(config.XMLData))
+	case binding.MIMEYAML:
+		return c.YAML(code, pick(config.YAMLData))
+	case binding.MIMETOML:
+		return c.TOML(code, pick(config.TOMLData))
+	case binding.MIMEPROTOBUF:
+		return c.ProtoBuf(code, pick(config.ProtoBufData))
+	case binding.MIMEMSGPACK:
+		return c.MsgPack(code, pick(config.MsgPackData))
+	default:
+		c.AbortWithStatus(http.StatusNotAcceptable)
+		return ErrNotAcceptable
+	}
+}
+
+// NegotiateFormat returns the offered type the Accept header prefers, the
+// first offer when there is no Accept header and "" when nothing matches.
+// Each offer takes the q-value of the most specific range matching it, on a
+// tie the more specific match and then the order of offered decide.
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	header := c.GetHeader("Accept")
+	if header == "" {
+		return offered[0]
+	}
+	ranges := parseAccept(header)
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, offer := range offered {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if r.specificity() > specificity && r.match(offer) {
+				q, specificity = r.q, r.specificity()
+			}
+		}
+		if q > bestQ || q == bestQ && q > 0 && specificity > bestSpecificity {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+	return best
+}
+
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaType)), "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
+		}
+		r := acceptRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+				r.q = q
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+func (r acceptRange) specificity() int {
+	switch {
+	case r.typ == "*":
+		return 0
+	case r.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+func (r acceptRange) match(offer string) bool {
+	typ, subtype, _ := strings.Cut(strings.ToLower(offer), "/")
+	return (r.typ == "*" || r.typ == typ) && (r.subtype == "*" || r.subtype == subtype)
+}
@@ -0,0 +1,67 @@
+package zorm
+
+import (
+	"github.com/caixr9527/zorm/binding"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	offered := []string{binding.MIMEJSON, binding.MIMEXML, binding.MIMEYAML}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", binding.MIMEJSON},
+		{"application/xml", binding.MIMEXML},
+		{"application/xml;q=0.5, application/yaml", binding.MIMEYAML},
+		{"text/*, application/*;q=0.2", binding.MIMEJSON},
+		{"application/xml, */*", binding.MIMEXML},
+		{"*/*;q=0.8, application/json;q=0", binding.MIMEXML},
+		{"text/html", ""},
+		{"APPLICATION/YAML;Q=1", binding.MIMEYAML},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		ctx := &Context{R: r}
+		if got := ctx.NegotiateFormat(offered...); got != tt.want {
+			t.Errorf("Accept %q: got %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	type goods struct {
+		ID   int    `json:"id" yaml:"id"`
+		Name string `json:"name" yaml:"name"`
+	}
+	engine := New()
+	engine.Group("goods").Get("/", func(ctx *Context) {
+		_ = ctx.Negotiate(http.StatusOK, Negotiate{
+			Offered: []string{binding.MIMEJSON, binding.MIMEYAML, binding.MIMEMSGPACK},
+			Data:    goods{ID: 1, Name: "pen"},
+		})
+	})
+	tests := []struct {
+		accept, contentType, body string
+		code                      int
+	}{
+		{"application/json", "application/json; charset=utf-8", `{"id":1,"name":"pen"}`, http.StatusOK},
+		{"application/yaml", "application/yaml; charset=utf-8", "id: 1\nname: pen\n", http.StatusOK},
+		{"application/msgpack", "application/msgpack", "\x82\xa2ID\x01\xa4Name\xa3pen", http.StatusOK},
+		{"image/png", "", "", http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/goods/", nil)
+		r.Header.Set("Accept", tt.accept)
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q %q", tt.accept, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
@@ -1,15 +1,29 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	zormlog "github.com/caixr9527/zorm/log"
+	"github.com/caixr9527/zorm/metrics"
+	"github.com/caixr9527/zorm/tracing"
 	"reflect"
 	"strings"
 	"time"
 )
 
+var (
+	queryDuration = metrics.NewHistogramVec("zorm_orm_query_duration_seconds",
+		"Latency of ORM statements by operation and table.", nil, "operation", "table")
+	queryErrors = metrics.NewCounterVec("zorm_orm_query_errors_total",
+		"Failed ORM statements by operation and table.", "operation", "table")
+)
+
+func init() {
+	metrics.MustRegister(queryDuration, queryErrors)
+}
+
 type ZDb struct {
 	db     *sql.DB
 	logger *zormlog.Logger
@@ -18,6 +32,7 @@ type ZDb struct {
 
 type DbSession struct {
 	db          *ZDb
+	ctx         context.Context
 	tx          *sql.Tx
 	beginTx     bool
 	tableName   string
@@ -74,6 +89,52 @@ func (db *ZDb) SetMaxIdleConns(n int) {
 	db.db.SetMaxIdleConns(n)
 }
 
+// WithContext makes the statements of the session use ctx, e.g. a
+// *zorm.Context, so they are cancelled with the request.
+func (session *DbSession) WithContext(ctx context.Context) *DbSession {
+	session.ctx = ctx
+	return session
+}
+
+func (session *DbSession) context() context.Context {
+	if session.ctx == nil {
+		return context.Background()
+	}
+	return session.ctx
+}
+
+func (session *DbSession) exec(stmt *sql.Stmt, operation string, args ...any) (sql.Result, error) {
+	ctx, span := session.startSpan(operation)
+	start := time.Now()
+	r, err := stmt.ExecContext(ctx, args...)
+	session.observe(span, operation, start, err)
+	return r, err
+}
+
+func (session *DbSession) query(stmt *sql.Stmt, operation string, args ...any) (*sql.Rows, error) {
+	ctx, span := session.startSpan(operation)
+	start := time.Now()
+	rows, err := stmt.QueryContext(ctx, args...)
+	session.observe(span, operation, start, err)
+	return rows, err
+}
+
+func (session *DbSession) startSpan(operation string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(session.context(), "orm "+operation+" "+session.tableName, tracing.SpanKindClient)
+	span.SetAttribute("db.operation", operation)
+	span.SetAttribute("db.sql.table", session.tableName)
+	return ctx, span
+}
+
+func (session *DbSession) observe(span *tracing.Span, operation string, start time.Time, err error) {
+	queryDuration.With(operation, session.tableName).ObserveSince(start)
+	if err != nil {
+		queryErrors.With(operation, session.tableName).Inc()
+	}
+	span.RecordError(err)
+	span.End()
+}
+
 func (session *DbSession) Table(name string) *DbSession {
 	session.tableName = name
 	return session
@@ -88,14 +149,14 @@ func (session *DbSession) Insert(data any) (int64, int64, error) {
 	session.db.logger.Info(query)
 	var stmt *sql.Stmt
 	if session.beginTx {
-		stmt, err = session.tx.Prepare(query)
+		stmt, err = session.tx.PrepareContext(session.context(), query)
 	} else {
-		stmt, err = session.db.db.Prepare(query)
+		stmt, err = session.db.db.PrepareContext(session.context(), query)
 	}
 	if err != nil {
 		return -1, -1, err
 	}
-	r, err := stmt.Exec(session.values...)
+	r, err := session.exec(stmt, "insert", session.values...)
 	if err != nil {
 		return -1, -1, err
 	}
@@ -172,11 +233,11 @@ func (session *DbSession) InsertBatch(data []any) (int64, int64, error) {
 		return -1, -1, err
 	}
 	session.db.logger.Info(sb.String())
-	stmt, err := session.db.db.Prepare(sb.String())
+	stmt, err := session.db.db.PrepareContext(session.context(), sb.String())
 	if err != nil {
 		return -1, -1, err
 	}
-	r, err := stmt.Exec(session.values...)
+	r, err := session.exec(stmt, "insert", session.values...)
 	if err != nil {
 		return -1, -1, err
 	}
@@ -254,12 +315,12 @@ func (session *DbSession) Update(data ...any) (int64, int64, error) {
 		sb.WriteString(query)
 		sb.WriteString(session.whereParam.String())
 		session.db.logger.Info(sb.String())
-		stmt, err := session.db.db.Prepare(sb.String())
+		stmt, err := session.db.db.PrepareContext(session.context(), sb.String())
 		if err != nil {
 			return -1, -1, err
 		}
 		session.values = append(session.values, session.whereValues...)
-		r, err := stmt.Exec(session.values...)
+		r, err := session.exec(stmt, "update", session.values...)
 		if err != nil {
 			return -1, -1, err
 		}
@@ -327,12 +388,12 @@ func (session *DbSession) Update(data ...any) (int64, int64, error) {
 	sb.WriteString(query)
 	sb.WriteString(session.whereParam.String())
 	session.db.logger.Info(sb.String())
-	stmt, err := session.db.db.Prepare(sb.String())
+	stmt, err := session.db.db.PrepareContext(session.context(), sb.String())
 	if err != nil {
 		return -1, -1, err
 	}
 	session.values = append(session.values, session.whereValues...)
-	r, err := stmt.Exec(session.values...)
+	r, err := session.exec(stmt, "update", session.values...)
 	if err != nil {
 		return -1, -1, err
 	}
@@ -354,11 +415,11 @@ func (session *DbSession) Delete() (int64, error) {
 	sb.WriteString(query)
 	sb.WriteString(session.whereParam.String())
 	session.db.logger.Info(sb.String())
-	stmt, err := session.db.db.Prepare(sb.String())
+	stmt, err := session.db.db.PrepareContext(session.context(), sb.String())
 	if err != nil {
 		return 0, err
 	}
-	exec, err := stmt.Exec(session.whereParam)
+	exec, err := session.exec(stmt, "delete", session.whereParam)
 	if err != nil {
 		return 0, err
 	}
@@ -379,11 +440,11 @@ func (session *DbSession) Select(data any, fields ...string) ([]any, error) {
 	sb.WriteString(query)
 	sb.WriteString(session.whereParam.String())
 	session.db.logger.Info(sb.String())
-	stmt, err := session.db.db.Prepare(sb.String())
+	stmt, err := session.db.db.PrepareContext(session.context(), sb.String())
 	if err != nil {
 		return nil, err
 	}
-	rows, err := stmt.Query(session.whereValues...)
+	rows, err := session.query(stmt, "select", session.whereValues...)
 	if err != nil {
 		return nil, err
 	}
@@ -450,11 +511,11 @@ func (session *DbSession) SelectOne(data any, fields ...string) error {
 	sb.WriteString(query)
 	sb.WriteString(session.whereParam.String())
 	session.db.logger.Info(sb.String())
-	stmt, err := session.db.db.Prepare(sb.String())
+	stmt, err := session.db.db.PrepareContext(session.context(), sb.String())
 	if err != nil {
 		return err
 	}
-	rows, err := stmt.Query(session.whereValues...)
+	rows, err := session.query(stmt, "select", session.whereValues...)
 	if err != nil {
 		return err
 	}
@@ -599,7 +660,7 @@ func (session *DbSession) Aggregate() (int64, error) {
 }
 
 func (session *DbSession) Begin() error {
-	tx, err := session.db.db.Begin()
+	tx, err := session.db.db.BeginTx(session.context(), nil)
 	if err != nil {
 		return err
 	}
@@ -627,11 +688,11 @@ func (session *DbSession) Rollback() error {
 }
 
 func (session *DbSession) Exec(sql string, values ...any) (int64, error) {
-	stmt, err := session.db.db.Prepare(sql)
+	stmt, err := session.db.db.PrepareContext(session.context(), sql)
 	if err != nil {
 		return 0, err
 	}
-	r, err := stmt.Exec(values)
+	r, err := session.exec(stmt, "exec", values)
 	if err != nil {
 		return 0, err
 	}
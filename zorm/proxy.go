@@ -0,0 +1,198 @@
+package zorm
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var defaultRemoteIPHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}
+
+// SetTrustedProxies sets the IPs or CIDRs of the proxies, e.g. mall-gateway,
+// whose forwarding headers ClientIP, Scheme and Host believe. No proxy is
+// trusted by default.
+func (e *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("zorm: invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			cidrs = append(cidrs, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("zorm: invalid trusted proxy %q: %w", proxy, err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	e.trustedCIDRs = cidrs
+	return nil
+}
+
+func (e *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, cidr := range e.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteIP returns the IP of the peer of the connection, without looking
+// at any header.
+func (c *Context) RemoteIP() string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.R.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(c.R.RemoteAddr)
+	}
+	return host
+}
+
+func (c *Context) fromTrustedProxy() bool {
+	ip := net.ParseIP(c.RemoteIP())
+	return ip != nil && c.engine.isTrustedProxy(ip)
+}
+
+// ClientIP returns the IP of the client. When the request comes from a
+// trusted proxy the headers in Engine.RemoteIPHeaders are read in order,
+// walking each chain back from the nearest hop to the first address that
+// is not a trusted proxy. Otherwise it is RemoteIP.
+func (c *Context) ClientIP() string {
+	remoteIP := c.RemoteIP()
+	if !c.fromTrustedProxy() {
+		return remoteIP
+	}
+	for _, name := range c.engine.RemoteIPHeaders {
+		var chain []string
+		if http.CanonicalHeaderKey(name) == "Forwarded" {
+			chain = forwardedValues(c.R.Header, "for")
+		} else {
+			chain = headerList(c.R.Header.Values(name))
+		}
+		if ip, ok := c.engine.clientFromChain(chain); ok {
+			return ip
+		}
+	}
+	return remoteIP
+}
+
+func (e *Engine) clientFromChain(chain []string) (string, bool) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseHop(chain[i])
+		if ip == nil {
+			// "unknown" or an obfuscated node, the chain can't be followed
+			return "", false
+		}
+		if i == 0 || !e.isTrustedProxy(ip) {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+// Scheme returns "http" or "https" as the client used it, taken from the
+// Forwarded or X-Forwarded-Proto headers of a trusted proxy.
+func (c *Context) Scheme() string {
+	if c.fromTrustedProxy() {
+		if proto := firstValue(forwardedValues(c.R.Header, "proto")); proto != "" {
+			return strings.ToLower(proto)
+		}
+		if proto := firstValue(headerList(c.R.Header.Values("X-Forwarded-Proto"))); proto != "" {
+			return strings.ToLower(proto)
+		}
+	}
+	if c.R.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host the client asked for, taken from the Forwarded or
+// X-Forwarded-Host headers of a trusted proxy.
+func (c *Context) Host() string {
+	if c.fromTrustedProxy() {
+		if host := firstValue(forwardedValues(c.R.Header, "host")); host != "" {
+			return host
+		}
+		if host := firstValue(headerList(c.R.Header.Values("X-Forwarded-Host"))); host != "" {
+			return host
+		}
+	}
+	return c.R.Host
+}
+
+// setForwardedHeaders adds this hop to the forwarding headers of a request
+// proxied by the gateway, the incoming ones are only kept when they come
+// from a trusted proxy. httputil.ReverseProxy appends the remote IP to
+// X-Forwarded-For itself.
+func (c *Context) setForwardedHeaders(req *http.Request) {
+	trusted := c.fromTrustedProxy()
+	prior := req.Header.Values("Forwarded")
+	if !trusted {
+		prior = nil
+		req.Header.Del("X-Forwarded-For")
+	}
+	node := c.RemoteIP()
+	if ip := net.ParseIP(node); ip != nil && ip.To4() == nil {
+		node = `"[` + node + `]"`
+	}
+	forwarded := fmt.Sprintf(`for=%s;host="%s";proto=%s`, node, c.Host(), c.Scheme())
+	req.Header.Set("Forwarded", strings.Join(append(prior, forwarded), ", "))
+	req.Header.Set("X-Forwarded-Host", c.Host())
+	req.Header.Set("X-Forwarded-Proto", c.Scheme())
+	req.Header.Set("X-Real-Ip", c.ClientIP())
+}
+
+// forwardedValues returns the values of param in the elements of the
+// Forwarded headers of RFC 7239, from the client side to the nearest hop.
+func forwardedValues(header http.Header, param string) []string {
+	var values []string
+	for _, element := range headerList(header.Values("Forwarded")) {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), param) {
+				values = append(values, strings.Trim(strings.TrimSpace(value), `"`))
+			}
+		}
+	}
+	return values
+}
+
+func headerList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// parseHop parses a node of a forwarding header, which may carry a port or
+// brackets around an IPv6 address.
+func parseHop(node string) net.IP {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	node = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+	return net.ParseIP(node)
+}
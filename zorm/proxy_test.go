@@ -0,0 +1,154 @@
+package zorm
+
+import (
+	"crypto/tls"
+	"github.com/caixr9527/zorm/gateway"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	engine := New()
+	if err := engine.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		remote string
+		header http.Header
+		want   string
+	}{
+		{"no headers", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"untrusted remote", "8.8.8.8:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}, "8.8.8.8"},
+		{"x-forwarded-for", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1, 10.0.0.2"}}, "1.1.1.1"},
+		{"spoofed left entry", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"6.6.6.6, 2.2.2.2, 10.0.0.2"}}, "2.2.2.2"},
+		{"several header lines", "192.168.1.1:80", http.Header{"X-Forwarded-For": {"3.3.3.3", "10.1.1.1"}}, "3.3.3.3"},
+		{"all trusted", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"x-real-ip", "10.0.0.1:1234", http.Header{"X-Real-Ip": {"4.4.4.4"}}, "4.4.4.4"},
+		{"forwarded first", "10.0.0.1:1234", http.Header{
+			"Forwarded":       {`for=5.5.5.5;proto=https, for="[2001:db8::1]:4711"`},
+			"X-Forwarded-For": {"1.1.1.1"},
+		}, "5.5.5.5"},
+		{"forwarded ipv6", "[2001:db8::2]:443", http.Header{"Forwarded": {`for="[2001:db9::1]:4711"`}}, "2001:db9::1"},
+		{"forwarded unknown", "10.0.0.1:1234", http.Header{
+			"Forwarded":       {"for=unknown"},
+			"X-Forwarded-For": {"1.1.1.1"},
+		}, "1.1.1.1"},
+		{"invalid", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"not-an-ip"}}, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		for k, v := range tt.header {
+			r.Header[k] = v
+		}
+		ctx := &Context{R: r, engine: engine}
+		if got := ctx.ClientIP(); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if err := engine.SetTrustedProxies([]string{"10.0.0.300"}); err == nil {
+		t.Error("invalid proxy accepted")
+	}
+}
+
+func TestSchemeAndHost(t *testing.T) {
+	engine := New()
+	engine.SetTrustedProxies([]string{"127.0.0.1"})
+	tests := []struct {
+		remote       string
+		tls          bool
+		header       http.Header
+		scheme, host string
+	}{
+		{"127.0.0.1:1", false, nil, "http", "example.com"},
+		{"127.0.0.1:1", true, nil, "https", "example.com"},
+		{"127.0.0.1:1", false, http.Header{"X-Forwarded-Proto": {"HTTPS"}, "X-Forwarded-Host": {"shop.com"}}, "https", "shop.com"},
+		{"127.0.0.1:1", false, http.Header{"Forwarded": {`proto=https;host="shop.com:81"`}}, "https", "shop.com:81"},
+		{"8.8.8.8:1", false, http.Header{"X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"shop.com"}}, "http", "example.com"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+		for k, v := range tt.header {
+			r.Header[k] = v
+		}
+		ctx := &Context{R: r, engine: engine}
+		if ctx.Scheme() != tt.scheme || ctx.Host() != tt.host {
+			t.Errorf("%v: got %s %s", tt.header, ctx.Scheme(), ctx.Host())
+		}
+	}
+}
+
+func TestFullPath(t *testing.T) {
+	engine := New()
+	var fullPath string
+	engine.Group("user").Get("/:id/info", func(ctx *Context) {
+		fullPath = ctx.FullPath()
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/user/7/info", nil))
+	if fullPath != "/user/:id/info" {
+		t.Errorf("got %q", fullPath)
+	}
+}
+
+func TestGatewayForwardedHeaders(t *testing.T) {
+	received := make(chan http.Header, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.ParseUint(portStr, 10, 64)
+
+	engine := New()
+	engine.OpenGateway = true
+	engine.SetGatewayConfig([]gateway.GWConfig{{Name: "order", Path: "/order/**", Host: host, Port: port}})
+
+	r := httptest.NewRequest(http.MethodGet, "http://shop.com/order/find", nil)
+	r.RemoteAddr = "8.8.8.8:1234"
+	r.Header.Set("X-Forwarded-For", "6.6.6.6")
+	r.Header.Set("Forwarded", "for=6.6.6.6")
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	header := <-received
+	if header.Get("X-Forwarded-For") != "8.8.8.8" || header.Get("Forwarded") != `for=8.8.8.8;host="shop.com";proto=http` ||
+		header.Get("X-Real-Ip") != "8.8.8.8" || header.Get("X-Forwarded-Host") != "shop.com" || header.Get("X-Forwarded-Proto") != "http" {
+		t.Errorf("untrusted: %v", header)
+	}
+
+	engine.SetTrustedProxies([]string{"8.8.8.8"})
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	header = <-received
+	if header.Get("X-Forwarded-For") != "6.6.6.6, 8.8.8.8" || header.Get("Forwarded") != `for=6.6.6.6, for=8.8.8.8;host="shop.com";proto=http` ||
+		header.Get("X-Real-Ip") != "6.6.6.6" {
+		t.Errorf("trusted: %v", header)
+	}
+}
+
+func TestGatewayBadGateway(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().(*net.TCPAddr)
+	// nothing listens on the port any more
+	l.Close()
+
+	engine := New()
+	engine.OpenGateway = true
+	engine.SetGatewayConfig([]gateway.GWConfig{{Name: "order", Path: "/order/**", Host: "127.0.0.1", Port: uint64(addr.Port)}})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/order/find", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("got %d", w.Code)
+	}
+}
@@ -27,6 +27,7 @@ func Recovery(next HandlerFunc) HandlerFunc {
 	return func(ctx *Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				ctx.Abort()
 				err2 := err.(error)
 				if err2 != nil {
 					var zError *zerror.ZError
@@ -1,14 +1,46 @@
 package render
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"regexp"
 )
 
+// callbackRegexp matches JSONP callbacks such as "cb" or "jQuery.cb_1",
+// anything else could inject script into the response.
+var callbackRegexp = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$]*(\.[A-Za-z_$][0-9A-Za-z_$]*)*$`)
+
+const maxCallbackLength = 128
+
 type JSON struct {
 	Data any
 }
 
+type IndentedJSON struct {
+	Data any
+}
+
+// SecureJSON prefixes JSON arrays with Prefix, e.g. "while(1);", so the
+// response can not be hijacked by a <script> tag on another site.
+type SecureJSON struct {
+	Prefix string
+	Data   any
+}
+
+// JsonpJSON wraps the JSON in a call of Callback. Plain JSON is written when
+// Callback is empty or is not a JavaScript identifier or member path.
+type JsonpJSON struct {
+	Callback string
+	Data     any
+}
+
+// AsciiJSON escapes every non-ASCII character as \uXXXX.
+type AsciiJSON struct {
+	Data any
+}
+
 func (j *JSON) Render(w http.ResponseWriter, code int) error {
 	j.WriteContentType(w)
 	w.WriteHeader(code)
@@ -23,3 +55,94 @@ func (j *JSON) Render(w http.ResponseWriter, code int) error {
 func (j *JSON) WriteContentType(w http.ResponseWriter) {
 	writeContentType(w, "application/json; charset=utf-8")
 }
+
+func (j *IndentedJSON) Render(w http.ResponseWriter, code int) error {
+	jsonData, err := json.MarshalIndent(j.Data, "", "    ")
+	if err != nil {
+		return err
+	}
+	j.WriteContentType(w)
+	w.WriteHeader(code)
+	_, err = w.Write(jsonData)
+	return err
+}
+
+func (j *IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/json; charset=utf-8")
+}
+
+func (j *SecureJSON) Render(w http.ResponseWriter, code int) error {
+	jsonData, err := json.Marshal(j.Data)
+	if err != nil {
+		return err
+	}
+	j.WriteContentType(w)
+	w.WriteHeader(code)
+	if bytes.HasPrefix(jsonData, []byte("[")) && bytes.HasSuffix(jsonData, []byte("]")) {
+		if _, err = w.Write([]byte(j.Prefix)); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(jsonData)
+	return err
+}
+
+func (j *SecureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/json; charset=utf-8")
+}
+
+func (j *JsonpJSON) Render(w http.ResponseWriter, code int) error {
+	jsonData, err := json.Marshal(j.Data)
+	if err != nil {
+		return err
+	}
+	j.WriteContentType(w)
+	w.WriteHeader(code)
+	if !j.validCallback() {
+		_, err = w.Write(jsonData)
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s(%s);", j.Callback, jsonData)
+	return err
+}
+
+func (j *JsonpJSON) validCallback() bool {
+	return len(j.Callback) <= maxCallbackLength && callbackRegexp.MatchString(j.Callback)
+}
+
+func (j *JsonpJSON) WriteContentType(w http.ResponseWriter) {
+	if !j.validCallback() {
+		writeContentType(w, "application/json; charset=utf-8")
+		return
+	}
+	writeContentType(w, "application/javascript; charset=utf-8")
+}
+
+func (j *AsciiJSON) Render(w http.ResponseWriter, code int) error {
+	jsonData, err := json.Marshal(j.Data)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, r := range string(jsonData) {
+		if r < 0x80 {
+			buf.WriteRune(r)
+			continue
+		}
+		if r > 0xFFFF {
+			// characters outside the BMP are written as a surrogate pair
+			r -= 0x10000
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+			continue
+		}
+		fmt.Fprintf(&buf, "\\u%04x", r)
+	}
+	j.WriteContentType(w)
+	w.WriteHeader(code)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (j *AsciiJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/json")
+}
@@ -0,0 +1,34 @@
+package render
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONRenders(t *testing.T) {
+	tests := []struct {
+		render      Render
+		contentType string
+		body        string
+	}{
+		{&IndentedJSON{Data: map[string]int{"a": 1}}, "application/json; charset=utf-8", "{\n    \"a\": 1\n}"},
+		{&SecureJSON{Prefix: "while(1);", Data: []int{1, 2}}, "application/json; charset=utf-8", "while(1);[1,2]"},
+		{&SecureJSON{Prefix: "while(1);", Data: map[string]int{"a": 1}}, "application/json; charset=utf-8", `{"a":1}`},
+		{&JsonpJSON{Callback: "cb", Data: 1}, "application/javascript; charset=utf-8", "cb(1);"},
+		{&JsonpJSON{Callback: "jQuery.cb_1", Data: 1}, "application/javascript; charset=utf-8", "jQuery.cb_1(1);"},
+		{&JsonpJSON{Callback: "</script>", Data: 1}, "application/json; charset=utf-8", "1"},
+		{&JsonpJSON{Callback: "alert(1)//", Data: 1}, "application/json; charset=utf-8", "1"},
+		{&JsonpJSON{Callback: "cb;alert(1)", Data: 1}, "application/json; charset=utf-8", "1"},
+		{&JsonpJSON{Data: 1}, "application/json; charset=utf-8", "1"},
+		{&AsciiJSON{Data: "中文 😀<"}, "application/json", `"\u4e2d\u6587 \ud83d\ude00\u003c"`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if err := tt.render.Render(w, 200); err != nil {
+			t.Fatal(err)
+		}
+		if w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Errorf("%T: got %q %q", tt.render, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
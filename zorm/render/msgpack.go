@@ -0,0 +1,25 @@
+package render
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+	"net/http"
+)
+
+type MsgPack struct {
+	Data any
+}
+
+func (m *MsgPack) Render(w http.ResponseWriter, code int) error {
+	data, err := msgpack.Marshal(m.Data)
+	if err != nil {
+		return err
+	}
+	m.WriteContentType(w)
+	w.WriteHeader(code)
+	_, err = w.Write(data)
+	return err
+}
+
+func (m *MsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/msgpack")
+}
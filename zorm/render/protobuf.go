@@ -0,0 +1,31 @@
+package render
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+)
+
+// ProtoBuf renders Data, which must be a proto.Message.
+type ProtoBuf struct {
+	Data any
+}
+
+func (p *ProtoBuf) Render(w http.ResponseWriter, code int) error {
+	message, ok := p.Data.(proto.Message)
+	if !ok {
+		return fmt.Errorf("render: %T is not a proto.Message", p.Data)
+	}
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	p.WriteContentType(w)
+	w.WriteHeader(code)
+	_, err = w.Write(data)
+	return err
+}
+
+func (p *ProtoBuf) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/x-protobuf")
+}
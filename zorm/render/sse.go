@@ -0,0 +1,76 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SSEvent is one Server-Sent Event. Strings and []byte are sent as they
+// are, other data as JSON.
+type SSEvent struct {
+	Event string
+	Id    string
+	Retry uint
+	Data  any
+}
+
+var sseReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// Render writes the event, the status is only written when code > 0 so a
+// stream can render many events after the first.
+func (s *SSEvent) Render(w http.ResponseWriter, code int) error {
+	var buf bytes.Buffer
+	if s.Id != "" {
+		buf.WriteString("id: " + sseField(s.Id) + "\n")
+	}
+	if s.Event != "" {
+		buf.WriteString("event: " + sseField(s.Event) + "\n")
+	}
+	if s.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", s.Retry)
+	}
+	var data string
+	switch d := s.Data.(type) {
+	case nil:
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	for _, line := range strings.Split(sseReplacer.Replace(data), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+	s.WriteContentType(w)
+	if code > 0 {
+		w.WriteHeader(code)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (s *SSEvent) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if header.Get("Content-Type") != "" {
+		return
+	}
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// stops nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+}
+
+// sseField drops line breaks, they would end the field early.
+func sseField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,25 @@
+package render
+
+import (
+	"gopkg.in/yaml.v3"
+	"net/http"
+)
+
+type YAML struct {
+	Data any
+}
+
+func (y *YAML) Render(w http.ResponseWriter, code int) error {
+	data, err := yaml.Marshal(y.Data)
+	if err != nil {
+		return err
+	}
+	y.WriteContentType(w)
+	w.WriteHeader(code)
+	_, err = w.Write(data)
+	return err
+}
+
+func (y *YAML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/yaml; charset=utf-8")
+}
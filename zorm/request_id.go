@@ -0,0 +1,57 @@
+package zorm
+
+import (
+	zormLog "github.com/caixr9527/zorm/log"
+	"github.com/caixr9527/zorm/requestid"
+)
+
+// RequestIDKey is the key of the request ID in Context.Keys and in the
+// fields of Context.Logger.
+const RequestIDKey = "request_id"
+
+type RequestIDConfig struct {
+	// Generator creates the ID of requests without a valid X-Request-ID,
+	// requestid.New by default.
+	Generator func() string
+}
+
+// RequestIDWithConfig keeps the X-Request-ID of the request or creates
+// one, and puts it on the Context, the request context, the response
+// header and every line of ctx.Logger. rpc.HttpClient and the TCP client
+// forward it when given ctx.
+func RequestIDWithConfig(conf RequestIDConfig, next HandlerFunc) HandlerFunc {
+	generator := conf.Generator
+	if generator == nil {
+		generator = requestid.New
+	}
+	return func(ctx *Context) {
+		id := ctx.R.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = generator()
+			ctx.R.Header.Set(requestid.Header, id)
+		}
+		ctx.Set(RequestIDKey, id)
+		ctx.R = ctx.R.WithContext(requestid.NewContext(ctx.R.Context(), id))
+		ctx.W.Header().Set(requestid.Header, id)
+		if ctx.Logger != nil {
+			ctx.Logger = ctx.Logger.WithFields(zormLog.Fields{RequestIDKey: id})
+		}
+		next(ctx)
+	}
+}
+
+func RequestID(next HandlerFunc) HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{}, next)
+}
+
+// RequestID returns the ID set by the RequestID middleware, or "".
+func (c *Context) RequestID() string {
+	if c.R != nil {
+		if id := requestid.FromContext(c.R.Context()); id != "" {
+			return id
+		}
+	}
+	id, _ := c.Get(RequestIDKey)
+	s, _ := id.(string)
+	return s
+}
@@ -0,0 +1,90 @@
+package zorm
+
+import (
+	"bytes"
+	"github.com/caixr9527/zorm/gateway"
+	zormLog "github.com/caixr9527/zorm/log"
+	"github.com/caixr9527/zorm/requestid"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var out bytes.Buffer
+	engine := New()
+	engine.Logger = zormLog.New()
+	engine.Logger.Outs = append(engine.Logger.Outs, &zormLog.LoggerWriter{Level: zormLog.Info, Out: &out})
+	engine.Logger.Formatter = &zormLog.TextFormatter{}
+	var seen string
+	engine.Use(RequestID)
+	engine.Group("api").Get("/id", func(ctx *Context) {
+		seen = requestid.FromContext(ctx)
+		ctx.Logger.Info("handled")
+		ctx.String(http.StatusOK, ctx.RequestID())
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"kept", "abc-123", true},
+		{"missing", "", false},
+		{"invalid", "has space", false},
+		{"too long", strings.Repeat("a", requestid.MaxLength+1), false},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		r := httptest.NewRequest(http.MethodGet, "/api/id", nil)
+		if tt.header != "" {
+			r.Header.Set(requestid.Header, tt.header)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		id := w.Header().Get(requestid.Header)
+		if tt.keep && id != tt.header || !tt.keep && (id == tt.header || !requestid.Valid(id)) {
+			t.Errorf("%s: got %q", tt.name, id)
+		}
+		if w.Body.String() != id || seen != id {
+			t.Errorf("%s: body %q, context %q, header %q", tt.name, w.Body.String(), seen, id)
+		}
+		if !strings.Contains(out.String(), RequestIDKey+"="+id) {
+			t.Errorf("%s: log %q", tt.name, out.String())
+		}
+	}
+}
+
+func TestGatewayRequestID(t *testing.T) {
+	received := make(chan string, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(requestid.Header)
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.ParseUint(portStr, 10, 64)
+
+	engine := New()
+	engine.OpenGateway = true
+	engine.SetGatewayConfig([]gateway.GWConfig{{Name: "order", Path: "/order/**", Host: host, Port: port}})
+
+	r := httptest.NewRequest(http.MethodGet, "/order/find", nil)
+	r.Header.Set(requestid.Header, "abc-123")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	if id := <-received; id != "abc-123" || w.Header().Get(requestid.Header) != id {
+		t.Errorf("forwarded %q, response %q", id, w.Header().Get(requestid.Header))
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/order/find", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	if id := <-received; !requestid.Valid(id) || w.Header().Get(requestid.Header) != id {
+		t.Errorf("generated %q, response %q", id, w.Header().Get(requestid.Header))
+	}
+}
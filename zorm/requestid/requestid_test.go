@@ -0,0 +1,40 @@
+package requestid
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := New(), New()
+	if !uuid.MatchString(a) || a == b {
+		t.Errorf("got %q and %q", a, b)
+	}
+	if got := FromContext(NewContext(context.Background(), a)); got != a {
+		t.Errorf("from context %q", got)
+	}
+	if got := FromContext(context.Background()); got != "" {
+		t.Errorf("empty context %q", got)
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"abc-123", true},
+		{"", false},
+		{"a b", false},
+		{"a\nb", false},
+		{"你好", false},
+		{string(make([]byte, MaxLength+1)), false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.id); got != tt.want {
+			t.Errorf("%q: got %v", tt.id, got)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package zorm
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+const noWritten = -1
+
+// ResponseWriter is the http.ResponseWriter of a Context, it records the
+// status and size of the response so middlewares can read them after the
+// handler ran.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Hijacker
+	http.Flusher
+	http.Pusher
+	// Status returns the status written, 200 when nothing was written yet.
+	Status() int
+	// Size returns the bytes of body written, -1 when the header was not
+	// written yet.
+	Size() int
+	// Written reports whether the header has been sent.
+	Written() bool
+	WriteString(s string) (int, error)
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status   int
+	size     int
+	hijacked bool
+	// statusCode points to the deprecated Context.StatusCode, it is kept
+	// equal to status
+	statusCode *int
+}
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.setStatus(http.StatusOK)
+	w.size = noWritten
+	w.hijacked = false
+}
+
+func (w *responseWriter) setStatus(code int) {
+	w.status = code
+	if w.statusCode != nil {
+		*w.statusCode = code
+	}
+}
+
+// WriteHeader sends the header once, later calls are ignored instead of
+// making net/http log a superfluous WriteHeader. After Hijack the status is
+// only recorded, e.g. 101 once a WebSocket handshake was written.
+func (w *responseWriter) WriteHeader(code int) {
+	if code <= 0 || w.Written() {
+		return
+	}
+	w.setStatus(code)
+	w.size = 0
+	if !w.hijacked {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *responseWriter) writeHeaderNow() {
+	if !w.Written() {
+		w.WriteHeader(w.status)
+	}
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.writeHeaderNow()
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.writeHeaderNow()
+	n, err := io.WriteString(w.ResponseWriter, s)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+func (w *responseWriter) Written() bool {
+	return w.size != noWritten
+}
+
+func (w *responseWriter) Flush() {
+	w.writeHeaderNow()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection, WriteHeader then records the status
+// written by the caller without sending anything.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
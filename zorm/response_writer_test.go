@@ -0,0 +1,88 @@
+package zorm
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &responseWriter{}
+	w.reset(rec)
+	if w.Written() || w.Status() != http.StatusOK || w.Size() != noWritten {
+		t.Fatalf("fresh writer: %v %d %d", w.Written(), w.Status(), w.Size())
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprint(w, "hello")
+	w.WriteString(" world")
+	if rec.Code != http.StatusCreated || w.Status() != http.StatusCreated || w.Size() != 11 || rec.Body.String() != "hello world" {
+		t.Errorf("got %d %d %d %q", rec.Code, w.Status(), w.Size(), rec.Body.String())
+	}
+	w.Flush()
+	if !rec.Flushed {
+		t.Error("not flushed")
+	}
+	if _, _, err := w.Hijack(); err != http.ErrNotSupported {
+		t.Errorf("hijack: %v", err)
+	}
+	if err := w.Push("/app.js", nil); err != http.ErrNotSupported {
+		t.Errorf("push: %v", err)
+	}
+}
+
+func TestResponseWriterStatus(t *testing.T) {
+	var out bytes.Buffer
+	engine := New()
+	var statusCode int
+	engine.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			next(ctx)
+			statusCode = ctx.StatusCode
+		}
+	})
+	engine.Use(func(next HandlerFunc) HandlerFunc {
+		return LoggingWithConfig(LoggingConfig{
+			Formatter: func(params *LogFormatterParams) string {
+				return fmt.Sprintf("%d %d\n", params.StatusCode, params.BodySize)
+			},
+			out: &out,
+		}, next)
+	})
+	group := engine.Group("w")
+	group.Get("/fprintf", func(ctx *Context) {
+		fmt.Fprintf(ctx.W, "id=%d", 7)
+	})
+	group.Get("/twice", func(ctx *Context) {
+		ctx.String(http.StatusAccepted, "first")
+		ctx.String(http.StatusBadRequest, "second")
+	})
+	group.Get("/empty", func(ctx *Context) {
+		ctx.JSON(http.StatusNoContent, map[string]int{"a": 1})
+	})
+	tests := []struct {
+		path string
+		code int
+		body string
+		log  string
+	}{
+		{"/w/fprintf", http.StatusOK, "id=7", "200 4\n"},
+		{"/w/twice", http.StatusAccepted, "firstsecond", "202 11\n"},
+		{"/w/empty", http.StatusNoContent, "", "204 0\n"},
+		{"/w/missing", http.StatusNotFound, "/w/missing not found \n", "404 22\n"},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body || out.String() != tt.log {
+			t.Errorf("%s: got %d %q, logged %q", tt.path, w.Code, w.Body.String(), out.String())
+		}
+		if statusCode != tt.code {
+			t.Errorf("%s: StatusCode %d", tt.path, statusCode)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package rpc
 
 import (
 	"context"
+	"github.com/caixr9527/zorm/tracing"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"net"
 	"time"
 )
@@ -23,6 +26,10 @@ func NewGrpcServer(addr string, ops ...GrpcOption) (*GrpcServer, error) {
 	}
 	grpcServer := &GrpcServer{}
 	grpcServer.listen = listener
+	grpcServer.ops = []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(TracingUnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(TracingStreamServerInterceptor()),
+	}
 	for _, v := range ops {
 		v.Apply(grpcServer)
 	}
@@ -108,9 +115,86 @@ func DefaultGrpcClientConfig() *GrpcClientConfig {
 	return &GrpcClientConfig{
 		dialOptions: []grpc.DialOption{
 			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithChainUnaryInterceptor(TracingUnaryClientInterceptor()),
 		},
 		DialTimeout: time.Second * 3,
 		ReadTimeout: time.Second * 3,
 		Block:       true,
 	}
 }
+
+// metadataCarrier lets the trace context travel in gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func startGrpcSpan(ctx context.Context, fullMethod string, kind tracing.SpanKind) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, fullMethod, kind)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", fullMethod)
+	return ctx, span
+}
+
+func endGrpcSpan(span *tracing.Span, err error) {
+	span.SetAttribute("rpc.grpc.status_code", int(status.Code(err)))
+	span.RecordError(err)
+	span.End()
+}
+
+// TracingUnaryServerInterceptor continues the trace of the caller in a
+// server span, NewGrpcServer installs it.
+func TracingUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx, span := startGrpcSpan(tracing.Extract(ctx, metadataCarrier(md)), info.FullMethod, tracing.SpanKindServer)
+		rsp, err := handler(ctx, req)
+		endGrpcSpan(span, err)
+		return rsp, err
+	}
+}
+
+type tracingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TracingStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		ctx, span := startGrpcSpan(tracing.Extract(ss.Context(), metadataCarrier(md)), info.FullMethod, tracing.SpanKindServer)
+		err := handler(srv, &tracingServerStream{ServerStream: ss, ctx: ctx})
+		endGrpcSpan(span, err)
+		return err
+	}
+}
+
+// TracingUnaryClientInterceptor records calls in client spans and sends
+// their context in the metadata, DefaultGrpcClientConfig installs it.
+func TracingUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := startGrpcSpan(ctx, method, tracing.SpanKindClient)
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		tracing.Inject(ctx, metadataCarrier(md))
+		err := invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+		endGrpcSpan(span, err)
+		return err
+	}
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"context"
+	"github.com/caixr9527/zorm/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestGrpcTracing(t *testing.T) {
+	exporter := tracing.NewMemoryExporter()
+	tracer := tracing.NewTracer("test", tracing.WithExporter(exporter))
+	previous := tracing.DefaultTracer()
+	tracing.SetDefaultTracer(tracer)
+	defer tracing.SetDefaultTracer(previous)
+
+	var handled tracing.SpanContext
+	server := TracingUnaryServerInterceptor()
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		// hand the outgoing metadata to the server side
+		md, _ := metadata.FromOutgoingContext(ctx)
+		_, err := server(metadata.NewIncomingContext(context.Background(), md), req, &grpc.UnaryServerInfo{FullMethod: method},
+			func(ctx context.Context, req any) (any, error) {
+				handled = tracing.SpanContextFromContext(ctx)
+				return nil, status.Error(codes.NotFound, "no goods")
+			})
+		return err
+	}
+	err := TracingUnaryClientInterceptor()(context.Background(), "/api.GoodsApi/Find", nil, nil, nil, invoker)
+	tracer.Shutdown(context.Background())
+	if status.Code(err) != codes.NotFound {
+		t.Fatal(err)
+	}
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	serverSpan, clientSpan := spans[0], spans[1]
+	if serverSpan.SpanContext != handled || serverSpan.ParentSpanID != clientSpan.SpanContext.SpanID ||
+		clientSpan.Kind != tracing.SpanKindClient || clientSpan.Status != tracing.StatusError ||
+		serverSpan.Attributes["rpc.grpc.status_code"] != int(codes.NotFound) {
+		t.Errorf("client %+v, server %+v", clientSpan, serverSpan)
+	}
+}
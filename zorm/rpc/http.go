@@ -3,9 +3,12 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/caixr9527/zorm/requestid"
+	"github.com/caixr9527/zorm/tracing"
 	"io"
 	"log"
 	"net/http"
@@ -35,11 +38,16 @@ func NewHttpClient() *HttpClient {
 }
 
 func (c *HttpClient) Get(url string, args map[string]any) ([]byte, error) {
+	return c.GetWithContext(context.Background(), url, args)
+}
+
+// GetWithContext is Get cancelled with ctx.
+func (c *HttpClient) GetWithContext(ctx context.Context, url string, args map[string]any) ([]byte, error) {
 	if args != nil && len(args) > 0 {
 		url = url + "?" + c.toValues(args)
 	}
 	log.Println(url)
-	request, err := http.NewRequest("GET", url, nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -66,8 +74,11 @@ func (c *HttpClient) JsonRequest(url string, args map[string]any) (*http.Request
 }
 
 func (c *HttpClient) PostForm(url string, args map[string]any) ([]byte, error) {
+	return c.PostFormWithContext(context.Background(), url, args)
+}
 
-	request, err := http.NewRequest("POST", url, strings.NewReader(c.toValues(args)))
+func (c *HttpClient) PostFormWithContext(ctx context.Context, url string, args map[string]any) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(c.toValues(args)))
 	if err != nil {
 		return nil, err
 	}
@@ -75,8 +86,12 @@ func (c *HttpClient) PostForm(url string, args map[string]any) ([]byte, error) {
 }
 
 func (c *HttpClient) PostJson(url string, args map[string]any) ([]byte, error) {
+	return c.PostJsonWithContext(context.Background(), url, args)
+}
+
+func (c *HttpClient) PostJsonWithContext(ctx context.Context, url string, args map[string]any) ([]byte, error) {
 	marshal, _ := json.Marshal(args)
-	request, err := http.NewRequest("POST", url, bytes.NewBuffer(marshal))
+	request, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(marshal))
 	if err != nil {
 		return nil, err
 	}
@@ -88,12 +103,26 @@ func (c *HttpClient) Response(req *http.Request) ([]byte, error) {
 }
 
 func (c *HttpClient) responseHandler(request *http.Request) ([]byte, error) {
-	response, err := c.client.Do(request)
+	if request.Header.Get(requestid.Header) == "" {
+		if id := requestid.FromContext(request.Context()); id != "" {
+			request.Header.Set(requestid.Header, id)
+		}
+	}
+	ctx, span := tracing.Start(request.Context(), "HTTP "+request.Method, tracing.SpanKindClient)
+	defer span.End()
+	span.SetAttribute("http.method", request.Method)
+	span.SetAttribute("http.url", request.URL.String())
+	tracing.Inject(ctx, request.Header)
+	response, err := c.client.Do(request.WithContext(ctx))
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
+	span.SetAttribute("http.status_code", response.StatusCode)
 	if response.StatusCode != http.StatusOK {
-		return nil, errors.New(fmt.Sprintf("response status is %d", response.StatusCode))
+		err := errors.New(fmt.Sprintf("response status is %d", response.StatusCode))
+		span.RecordError(err)
+		return nil, err
 	}
 	reader := bufio.NewReader(response.Body)
 	defer response.Body.Close()
@@ -151,6 +180,12 @@ func (c *HttpClient) RegisterHttpService(name string, service ZService) {
 	c.serviceMap[name] = service
 }
 func (c *HttpClient) Do(service string, method string) ZService {
+	return c.DoWithContext(context.Background(), service, method)
+}
+
+// DoWithContext is Do with the calls of the returned service cancelled
+// with ctx.
+func (c *HttpClient) DoWithContext(ctx context.Context, service string, method string) ZService {
 	zService, ok := c.serviceMap[service]
 	if !ok {
 		panic(errors.New("service not found"))
@@ -188,13 +223,13 @@ func (c *HttpClient) Do(service string, method string) ZService {
 
 	f := func(args map[string]any) ([]byte, error) {
 		if methodType == GET {
-			return c.Get(httpConfig.Prefix()+path, args)
+			return c.GetWithContext(ctx, httpConfig.Prefix()+path, args)
 		}
 		if methodType == POST_FORM {
-			return c.PostForm(httpConfig.Prefix()+path, args)
+			return c.PostFormWithContext(ctx, httpConfig.Prefix()+path, args)
 		}
 		if methodType == POST_JSON {
-			return c.PostForm(httpConfig.Prefix()+path, args)
+			return c.PostFormWithContext(ctx, httpConfig.Prefix()+path, args)
 		}
 		return nil, errors.New("no match method type")
 	}
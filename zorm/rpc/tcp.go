@@ -9,17 +9,40 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/caixr9527/zorm/metrics"
 	"github.com/caixr9527/zorm/register"
+	"github.com/caixr9527/zorm/requestid"
+	"github.com/caixr9527/zorm/tracing"
 	"github.com/golang/protobuf/proto"
 	"golang.org/x/time/rate"
 	"google.golang.org/protobuf/types/known/structpb"
 	"io"
 	"log"
+	"math"
 	"net"
 	"reflect"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	clientCalls = metrics.NewCounterVec("zorm_rpc_client_calls_total",
+		"TCP RPC calls by service, method and code, -1 when no response came.", "service", "method", "code")
+	clientDuration = metrics.NewHistogramVec("zorm_rpc_client_call_duration_seconds",
+		"Latency of TCP RPC calls, retries included, by service and method.", nil, "service", "method")
+	serverHandled = metrics.NewCounterVec("zorm_rpc_server_handled_total",
+		"TCP RPC requests handled by service, method and code.", "service", "method", "code")
+	serverDuration = metrics.NewHistogramVec("zorm_rpc_server_handling_seconds",
+		"Time spent in the service methods by service and method.", nil, "service", "method")
+	serverRejected = metrics.NewCounter("zorm_rpc_server_rejected_total",
+		"TCP RPC requests rejected by the limiter of the server.")
+)
+
+func init() {
+	metrics.MustRegister(clientCalls, clientDuration, serverHandled, serverDuration, serverRejected)
+}
+
 type Serializer interface {
 	Serialize(data any) ([]byte, error)
 	Deserialize(data []byte, target any) error
@@ -107,7 +130,11 @@ func (g GzipCompress) UnCompress(data []byte) ([]byte, error) {
 }
 
 const MagicNumber byte = 0x1d
-const Version = 0x01
+
+// Version 2 adds the metadata section after the fixed header. Frames
+// without metadata are still written as version 1 so older peers can
+// decode them.
+const Version = 0x02
 
 type MessageType byte
 
@@ -126,6 +153,9 @@ type Header struct {
 	CompressType   CompressType
 	SerializerType SerializerType
 	RequestId      int64
+	// Meta is sent between the fixed header and the body, e.g. the
+	// X-Request-ID of the call.
+	Meta map[string]string
 }
 
 type MsgRpcMessage struct {
@@ -210,7 +240,6 @@ func (c MsgTcpConn) Send(rsp *MsgRpcResponse) error {
 	}
 	headers := make([]byte, 17)
 	headers[0] = MagicNumber
-	headers[1] = Version
 	headers[6] = byte(msgResponse)
 	headers[7] = byte(rsp.CompressType)
 	headers[8] = byte(rsp.SerializerType)
@@ -245,7 +274,8 @@ func (c MsgTcpConn) Send(rsp *MsgRpcResponse) error {
 	if err != nil {
 		return err
 	}
-	fullLen := 17 + len(body)
+	headers = appendMeta(headers, nil)
+	fullLen := len(headers) + len(body)
 	binary.BigEndian.PutUint32(headers[2:6], uint32(fullLen))
 
 	_, err = c.conn.Write(headers[:])
@@ -291,6 +321,7 @@ func (s *MsgTcpServer) readHandler(conn *MsgTcpConn) {
 	defer cancel()
 	err := s.Limiter.WaitN(ctx, 1)
 	if err != nil {
+		serverRejected.Inc()
 		rsp := &MsgRpcResponse{}
 		rsp.Code = 403
 		rsp.Msg = err.Error()
@@ -306,6 +337,11 @@ func (s *MsgTcpServer) readHandler(conn *MsgTcpConn) {
 		return
 	}
 	if msg.Header.MessageType == msgRequest {
+		callCtx := context.Background()
+		if id := msg.Header.Meta[requestid.Header]; id != "" {
+			callCtx = requestid.NewContext(callCtx, id)
+		}
+		callCtx = tracing.Extract(callCtx, tracing.MapCarrier(msg.Header.Meta))
 		if msg.Header.SerializerType == ProtoBuff {
 			req := msg.Data.(*Request)
 			rsp := &MsgRpcResponse{RequestId: req.RequestId}
@@ -330,13 +366,18 @@ func (s *MsgTcpServer) readHandler(conn *MsgTcpConn) {
 				return
 			}
 
-			args := make([]reflect.Value, len(req.Args))
+			spanCtx, span := startServerSpan(callCtx, serviceName, methodName)
+			defer span.End()
+			args := contextArgs(method, spanCtx)
+			offset := len(args)
 			for i := range req.Args {
 				of := reflect.ValueOf(req.Args[i].AsInterface())
-				of = of.Convert(method.Type().In(i))
-				args[i] = of
+				of = of.Convert(method.Type().In(i + offset))
+				args = append(args, of)
 			}
+			start := time.Now()
 			result := method.Call(args)
+			serverDuration.With(serviceName, methodName).ObserveSince(start)
 
 			results := make([]any, len(result))
 			for i, v := range result {
@@ -346,10 +387,13 @@ func (s *MsgTcpServer) readHandler(conn *MsgTcpConn) {
 			if ok {
 				rsp.Code = 500
 				rsp.Msg = err.Error()
+				serverHandled.With(serviceName, methodName, "500").Inc()
+				span.RecordError(err)
 				conn.rspChan <- rsp
 				return
 			}
 			rsp.Code = 200
+			serverHandled.With(serviceName, methodName, "200").Inc()
 			rsp.Data = results[0]
 			conn.rspChan <- rsp
 		} else {
@@ -376,11 +420,15 @@ func (s *MsgTcpServer) readHandler(conn *MsgTcpConn) {
 				return
 			}
 			args := req.Args
-			var valuesArg []reflect.Value
+			spanCtx, span := startServerSpan(callCtx, serviceName, methodName)
+			defer span.End()
+			valuesArg := contextArgs(method, spanCtx)
 			for _, v := range args {
 				valuesArg = append(valuesArg, reflect.ValueOf(v))
 			}
+			start := time.Now()
 			result := method.Call(valuesArg)
+			serverDuration.With(serviceName, methodName).ObserveSince(start)
 			results := make([]any, len(result))
 			for i, v := range result {
 				results[i] = v.Interface()
@@ -389,10 +437,13 @@ func (s *MsgTcpServer) readHandler(conn *MsgTcpConn) {
 			if ok {
 				rsp.Code = 500
 				rsp.Msg = err.Error()
+				serverHandled.With(serviceName, methodName, "500").Inc()
+				span.RecordError(err)
 				conn.rspChan <- rsp
 				return
 			}
 			rsp.Code = 200
+			serverHandled.With(serviceName, methodName, "200").Inc()
 			rsp.Data = results[0]
 			conn.rspChan <- rsp
 		}
@@ -400,6 +451,86 @@ func (s *MsgTcpServer) readHandler(conn *MsgTcpConn) {
 	}
 }
 
+func startServerSpan(ctx context.Context, serviceName, methodName string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, serviceName+"/"+methodName, tracing.SpanKindServer)
+	span.SetAttribute("rpc.system", "zorm")
+	span.SetAttribute("rpc.service", serviceName)
+	span.SetAttribute("rpc.method", methodName)
+	return ctx, span
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// contextArgs returns ctx as the first argument of methods taking a
+// context.Context first, it isn't sent by the client.
+func contextArgs(method reflect.Value, ctx context.Context) []reflect.Value {
+	t := method.Type()
+	if t.NumIn() > 0 && t.In(0) == contextType {
+		return []reflect.Value{reflect.ValueOf(ctx)}
+	}
+	return nil
+}
+
+// appendMeta sets the frame version in headers and appends the metadata
+// section. Without metadata the frame stays version 1.
+func appendMeta(headers []byte, meta map[string]string) []byte {
+	if len(meta) == 0 {
+		headers[1] = 0x01
+		return headers
+	}
+	headers[1] = Version
+	return append(headers, encodeMeta(meta)...)
+}
+
+// encodeMeta encodes meta as a uint16 count followed by entries of a
+// uint8 key length, the key, a uint16 value length and the value.
+func encodeMeta(meta map[string]string) []byte {
+	buf := make([]byte, 2, 2+len(meta)*16)
+	n := 0
+	for k, v := range meta {
+		if len(k) > math.MaxUint8 || len(v) > math.MaxUint16 {
+			continue
+		}
+		buf = append(buf, byte(len(k)))
+		buf = append(buf, k...)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(v)))
+		buf = append(buf, v...)
+		n++
+	}
+	binary.BigEndian.PutUint16(buf, uint16(n))
+	return buf
+}
+
+// decodeMeta decodes the metadata at the start of data and returns the
+// rest.
+func decodeMeta(data []byte) (map[string]string, []byte, error) {
+	errMeta := errors.New("meta error")
+	if len(data) < 2 {
+		return nil, nil, errMeta
+	}
+	count := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	meta := make(map[string]string, count)
+	for i := 0; i < count; i++ {
+		if len(data) < 1 {
+			return nil, nil, errMeta
+		}
+		kl := int(data[0])
+		if len(data) < 1+kl+2 {
+			return nil, nil, errMeta
+		}
+		k := string(data[1 : 1+kl])
+		data = data[1+kl:]
+		vl := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+vl {
+			return nil, nil, errMeta
+		}
+		meta[k] = string(data[2 : 2+vl])
+		data = data[2+vl:]
+	}
+	return meta, data, nil
+}
+
 func (s *MsgTcpServer) writeHandler(conn *MsgTcpConn) {
 	select {
 	case rsp := <-conn.rspChan:
@@ -428,7 +559,7 @@ func decodeFrame(conn net.Conn) (*MsgRpcMessage, error) {
 	messageType := headers[6]
 	compressType := headers[7]
 	seType := headers[8]
-	requestId := int64(binary.BigEndian.Uint32(headers[9:]))
+	requestId := int64(binary.BigEndian.Uint64(headers[9:]))
 
 	msg := &MsgRpcMessage{
 		Header: &Header{},
@@ -442,12 +573,21 @@ func decodeFrame(conn net.Conn) (*MsgRpcMessage, error) {
 	msg.Header.RequestId = requestId
 
 	bodyLen := fullLength - 17
+	if bodyLen < 0 {
+		return nil, errors.New("full length error")
+	}
 	body := make([]byte, bodyLen)
 
 	_, err = io.ReadFull(conn, body)
 	if err != nil {
 		return nil, err
 	}
+	if version >= 0x02 {
+		msg.Header.Meta, body, err = decodeMeta(body)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	compress := loadCompress(CompressType(compressType))
 	if compress == nil {
@@ -540,6 +680,9 @@ var DefaultOption = TcpClientOption{
 	Port:              9222,
 }
 
+// nextRequestId numbers the requests of all clients of the process.
+var nextRequestId atomic.Int64
+
 func NewTcpClient(option TcpClientOption) *TcpClient {
 	return &TcpClient{option: option}
 }
@@ -553,7 +696,7 @@ func (c *TcpClient) Connect() error {
 	if err != nil {
 		return err
 	}
-	addr := fmt.Sprintf("%s:%d", ip, port)
+	addr := net.JoinHostPort(ip, strconv.FormatUint(port, 10))
 	conn, err := net.DialTimeout("tcp", addr, c.option.ConnectionTimeout)
 	if err != nil {
 		return err
@@ -569,17 +712,46 @@ func (c *TcpClient) Close() error {
 	return nil
 }
 
-func (c *TcpClient) Invoke(context context.Context, serviceName string, methodName string, args []any) (any, error) {
+// Invoke sends the request and waits for the response, giving up with
+// ctx.Err() when ctx is done first.
+func (c *TcpClient) Invoke(ctx context.Context, serviceName string, methodName string, args []any) (any, error) {
+	// with no trace to continue and nowhere to export, a span would only
+	// turn the frame into version 2
+	if !tracing.SpanContextFromContext(ctx).IsValid() && !tracing.DefaultTracer().Exporting() {
+		return c.invoke(ctx, serviceName, methodName, args)
+	}
+	ctx, span := tracing.Start(ctx, serviceName+"/"+methodName, tracing.SpanKindClient)
+	defer span.End()
+	span.SetAttribute("rpc.system", "zorm")
+	span.SetAttribute("rpc.service", serviceName)
+	span.SetAttribute("rpc.method", methodName)
+	result, err := c.invoke(ctx, serviceName, methodName, args)
+	if err != nil {
+		span.RecordError(err)
+	} else if rsp, ok := result.(*MsgRpcResponse); ok {
+		span.SetAttribute("rpc.code", int(rsp.Code))
+		if rsp.Code != 200 {
+			span.SetStatus(tracing.StatusError, rsp.Msg)
+		}
+	}
+	return result, err
+}
+
+func (c *TcpClient) invoke(ctx context.Context, serviceName string, methodName string, args []any) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// a zero deadline clears the one left by a previous call
+	deadline, _ := ctx.Deadline()
+	c.conn.SetDeadline(deadline)
 	req := &MsgRpcRequest{}
-	// todo uuid
-	req.RequestId = 1
+	req.RequestId = nextRequestId.Add(1)
 	req.ServiceName = serviceName
 	req.MethodName = methodName
 	req.Args = args
 
 	headers := make([]byte, 17)
 	headers[0] = MagicNumber
-	headers[1] = Version
 	headers[6] = byte(msgRequest)
 	headers[7] = byte(c.option.CompressType)
 	headers[8] = byte(c.option.SerializerType)
@@ -592,7 +764,7 @@ func (c *TcpClient) Invoke(context context.Context, serviceName string, methodNa
 	var err error
 	if c.option.SerializerType == ProtoBuff {
 		pReq := &Request{}
-		pReq.RequestId = 1
+		pReq.RequestId = req.RequestId
 		pReq.ServiceName = serviceName
 		pReq.MethodName = methodName
 		listValue, err := structpb.NewList(args)
@@ -617,7 +789,13 @@ func (c *TcpClient) Invoke(context context.Context, serviceName string, methodNa
 	if err != nil {
 		return nil, err
 	}
-	fullLen := 17 + len(body)
+	meta := make(map[string]string)
+	if id := requestid.FromContext(ctx); id != "" {
+		meta[requestid.Header] = id
+	}
+	tracing.Inject(ctx, tracing.MapCarrier(meta))
+	headers = appendMeta(headers, meta)
+	fullLen := len(headers) + len(body)
 	binary.BigEndian.PutUint32(headers[2:6], uint32(fullLen))
 	_, err = c.conn.Write(headers[:])
 	if err != nil {
@@ -628,10 +806,16 @@ func (c *TcpClient) Invoke(context context.Context, serviceName string, methodNa
 	if err != nil {
 		return nil, err
 	}
-	rspChan := make(chan *MsgRpcResponse)
+	rspChan := make(chan *MsgRpcResponse, 1)
 	go c.readHandler(rspChan)
-	rsp := <-rspChan
-	return rsp, nil
+	select {
+	case rsp := <-rspChan:
+		return rsp, nil
+	case <-ctx.Done():
+		// unblocks readHandler
+		c.conn.Close()
+		return nil, ctx.Err()
+	}
 }
 
 func (c *TcpClient) readHandler(rspChan chan *MsgRpcResponse) {
@@ -681,6 +865,18 @@ func NewTcpClientProxy(option TcpClientOption) *TcpClientProxy {
 
 // todo args换一种格式,map
 func (p *TcpClientProxy) Call(ctx context.Context, serviceName string, methodName string, args []any) (any, error) {
+	start := time.Now()
+	result, err := p.call(ctx, serviceName, methodName, args)
+	code := "-1"
+	if rsp, ok := result.(*MsgRpcResponse); ok {
+		code = strconv.Itoa(int(rsp.Code))
+	}
+	clientCalls.With(serviceName, methodName, code).Inc()
+	clientDuration.With(serviceName, methodName).ObserveSince(start)
+	return result, err
+}
+
+func (p *TcpClientProxy) call(ctx context.Context, serviceName string, methodName string, args []any) (any, error) {
 	client := NewTcpClient(p.option)
 	p.client = client
 	client.ServiceName = serviceName
@@ -691,7 +887,7 @@ func (p *TcpClientProxy) Call(ctx context.Context, serviceName string, methodNam
 	for i := 0; i < p.option.Retries; i++ {
 		result, err := client.Invoke(ctx, serviceName, methodName, args)
 		if err != nil {
-			if i >= p.option.Retries-1 {
+			if i >= p.option.Retries-1 || ctx.Err() != nil {
 				//todo
 				log.Println(errors.New("already retry all time"))
 				client.Close()
@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/caixr9527/zorm/requestid"
+	"github.com/caixr9527/zorm/tracing"
+	"net"
+	"testing"
+	"time"
+)
+
+type echoService struct{}
+
+func (*echoService) Echo(ctx context.Context, s string) (string, error) {
+	return requestid.FromContext(ctx) + ":" + s, nil
+}
+
+func pipeClient() *TcpClient {
+	server, client := net.Pipe()
+	s := &MsgTcpServer{serviceMap: map[string]any{"echo": &echoService{}}}
+	s.SetLimiter(100, 100)
+	conn := &MsgTcpConn{conn: server, rspChan: make(chan *MsgRpcResponse, 1)}
+	go s.readHandler(conn)
+	go s.writeHandler(conn)
+	c := NewTcpClient(DefaultOption)
+	c.conn = client
+	return c
+}
+
+func invokeEcho(t *testing.T, ctx context.Context) *MsgRpcResponse {
+	t.Helper()
+	c := pipeClient()
+	defer c.Close()
+	rsp, err := c.Invoke(ctx, "echo", "Echo", []any{"hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rsp.(*MsgRpcResponse)
+}
+
+func TestTcpRequestID(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "abc-123")
+	first := invokeEcho(t, ctx)
+	if first.Code != 200 || first.Data != "abc-123:hi" {
+		t.Errorf("got %d %v %s", first.Code, first.Data, first.Msg)
+	}
+	second := invokeEcho(t, context.Background())
+	if second.Data != ":hi" {
+		t.Errorf("without id got %v", second.Data)
+	}
+	if second.RequestId <= first.RequestId {
+		t.Errorf("request ids %d then %d", first.RequestId, second.RequestId)
+	}
+}
+
+type deadlineConn struct {
+	net.Conn
+	deadline time.Time
+}
+
+func (c *deadlineConn) SetDeadline(t time.Time) error {
+	c.deadline = t
+	return c.Conn.SetDeadline(t)
+}
+
+func TestTcpDeadlineReset(t *testing.T) {
+	c := pipeClient()
+	defer c.Close()
+	// left over from a call with a deadline
+	conn := &deadlineConn{Conn: c.conn, deadline: time.Now()}
+	c.conn = conn
+	rsp, err := c.Invoke(context.Background(), "echo", "Echo", []any{"hi"})
+	if err != nil || rsp.(*MsgRpcResponse).Code != 200 {
+		t.Fatalf("got %v %v", rsp, err)
+	}
+	if !conn.deadline.IsZero() {
+		t.Errorf("deadline %v was not cleared", conn.deadline)
+	}
+}
+
+func TestFrameVersion(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	rsp := &MsgRpcResponse{RequestId: 7, Code: 200, Data: "ok", SerializerType: Gob, CompressType: Gzip}
+	go MsgTcpConn{conn: server}.Send(rsp)
+	msg, err := decodeFrame(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.Version != 0x01 || msg.Header.Meta != nil || msg.Data.(*MsgRpcResponse).Data != "ok" {
+		t.Errorf("got version %d meta %v data %v", msg.Header.Version, msg.Header.Meta, msg.Data)
+	}
+	headers := appendMeta(make([]byte, 17), map[string]string{"k": "v"})
+	if headers[1] != Version || len(headers) != 17+len(encodeMeta(map[string]string{"k": "v"})) {
+		t.Errorf("with meta got version %d length %d", headers[1], len(headers))
+	}
+}
+
+func TestTcpTracingFrameVersion(t *testing.T) {
+	sent := func(ctx context.Context) *Header {
+		server, client := net.Pipe()
+		defer server.Close()
+		c := NewTcpClient(DefaultOption)
+		c.conn = client
+		defer c.Close()
+		go c.Invoke(ctx, "echo", "Echo", []any{"hi"})
+		msg, err := decodeFrame(server)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg.Header
+	}
+	if h := sent(context.Background()); h.Version != 0x01 {
+		t.Errorf("untraced call sent version %d meta %v", h.Version, h.Meta)
+	}
+	ctx, span := tracing.Start(context.Background(), "root", tracing.SpanKindServer)
+	defer span.End()
+	if h := sent(ctx); h.Version != Version || h.Meta[tracing.TraceparentHeader] == "" {
+		t.Errorf("traced call sent version %d meta %v", h.Version, h.Meta)
+	}
+}
+
+func TestMeta(t *testing.T) {
+	meta := map[string]string{requestid.Header: "abc-123", "traceparent": "00-01"}
+	data := append(encodeMeta(meta), "body"...)
+	got, rest, err := decodeMeta(data)
+	if err != nil || len(got) != 2 || got["traceparent"] != "00-01" || string(rest) != "body" {
+		t.Errorf("got %v %q %v", got, rest, err)
+	}
+	if _, _, err := decodeMeta(data[:5]); err == nil {
+		t.Error("truncated meta decoded")
+	}
+}
+
+func TestTcpTracing(t *testing.T) {
+	exporter := tracing.NewMemoryExporter()
+	tracer := tracing.NewTracer("test", tracing.WithExporter(exporter))
+	previous := tracing.DefaultTracer()
+	tracing.SetDefaultTracer(tracer)
+	defer tracing.SetDefaultTracer(previous)
+
+	ctx, root := tracing.Start(context.Background(), "root", tracing.SpanKindServer)
+	invokeEcho(t, ctx)
+	root.End()
+	tracer.Shutdown(context.Background())
+
+	byName := make(map[string]tracing.SpanData)
+	for _, span := range exporter.Spans() {
+		byName[fmt.Sprint(span.Kind, span.Name)] = span
+	}
+	client, server := byName["clientecho/Echo"], byName["serverecho/Echo"]
+	if client.ParentSpanID != root.SpanContext().SpanID || server.ParentSpanID != client.SpanContext.SpanID ||
+		server.SpanContext.TraceID != root.SpanContext().TraceID {
+		t.Errorf("root %v, client %+v, server %+v", root.SpanContext(), client, server)
+	}
+}
@@ -0,0 +1,217 @@
+package zorm
+
+import (
+	"context"
+	"errors"
+	"github.com/caixr9527/zorm/config"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type ServerOption interface {
+	Apply(s *http.Server)
+}
+
+type DefaultServerOption struct {
+	f func(s *http.Server)
+}
+
+func (d *DefaultServerOption) Apply(s *http.Server) {
+	d.f(s)
+}
+
+func WithReadTimeout(timeout time.Duration) ServerOption {
+	return &DefaultServerOption{
+		f: func(s *http.Server) {
+			s.ReadTimeout = timeout
+		},
+	}
+}
+
+func WithReadHeaderTimeout(timeout time.Duration) ServerOption {
+	return &DefaultServerOption{
+		f: func(s *http.Server) {
+			s.ReadHeaderTimeout = timeout
+		},
+	}
+}
+
+func WithWriteTimeout(timeout time.Duration) ServerOption {
+	return &DefaultServerOption{
+		f: func(s *http.Server) {
+			s.WriteTimeout = timeout
+		},
+	}
+}
+
+func WithIdleTimeout(timeout time.Duration) ServerOption {
+	return &DefaultServerOption{
+		f: func(s *http.Server) {
+			s.IdleTimeout = timeout
+		},
+	}
+}
+
+func WithMaxHeaderBytes(n int) ServerOption {
+	return &DefaultServerOption{
+		f: func(s *http.Server) {
+			s.MaxHeaderBytes = n
+		},
+	}
+}
+
+// SetServerOptions sets the options applied to the servers created by Run
+// and RunTLS.
+func (e *Engine) SetServerOptions(ops ...ServerOption) {
+	e.serverOptions = append(e.serverOptions, ops...)
+}
+
+func (e *Engine) newServer(addr string, ops []ServerOption) *http.Server {
+	srv := &http.Server{Addr: addr, Handler: e}
+	for _, op := range e.serverOptions {
+		op.Apply(srv)
+	}
+	for _, op := range ops {
+		op.Apply(srv)
+	}
+	return srv
+}
+
+// Run serves http on addr until Shutdown is called and returns once
+// Shutdown has, a clean shutdown returns nil.
+func (e *Engine) Run(addr string, ops ...ServerOption) error {
+	return e.RunServer(e.newServer(addr, ops))
+}
+
+func (e *Engine) RunTLS(addr, certFile, keyFile string, ops ...ServerOption) error {
+	return e.serve(e.newServer(addr, ops), certFile, keyFile)
+}
+
+// RunServer serves with a caller configured server, the engine is used as
+// the handler when srv.Handler is nil.
+func (e *Engine) RunServer(srv *http.Server) error {
+	return e.serve(srv, "", "")
+}
+
+func (e *Engine) serve(srv *http.Server, certFile, keyFile string) error {
+	if srv.Handler == nil {
+		srv.Handler = e
+	}
+	e.serverMu.Lock()
+	if e.inShutdown {
+		e.serverMu.Unlock()
+		return nil
+	}
+	e.servers = append(e.servers, srv)
+	e.serverMu.Unlock()
+	var err error
+	if certFile != "" || keyFile != "" || srv.TLSConfig != nil {
+		err = srv.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		// let the OnShutdown hooks finish before the caller exits
+		e.serverMu.Lock()
+		done := e.shutdownDone
+		e.serverMu.Unlock()
+		if done != nil {
+			<-done
+		}
+		return nil
+	}
+	return err
+}
+
+// RunFromConfig starts the server described by the [server] section of the
+// config file, TLS is used when a cert and key are configured.
+func (e *Engine) RunFromConfig() error {
+	conf := config.Current().Zorm().Server
+	if conf.OpenGateway {
+		e.OpenGateway = true
+	}
+	if len(conf.TrustedProxies) > 0 {
+		if err := e.SetTrustedProxies(conf.TrustedProxies); err != nil {
+			return err
+		}
+	}
+	var ops []ServerOption
+	if conf.ReadTimeout > 0 {
+		ops = append(ops, WithReadTimeout(conf.ReadTimeout))
+	}
+	if conf.ReadHeaderTimeout > 0 {
+		ops = append(ops, WithReadHeaderTimeout(conf.ReadHeaderTimeout))
+	}
+	if conf.WriteTimeout > 0 {
+		ops = append(ops, WithWriteTimeout(conf.WriteTimeout))
+	}
+	if conf.IdleTimeout > 0 {
+		ops = append(ops, WithIdleTimeout(conf.IdleTimeout))
+	}
+	if conf.MaxHeaderBytes > 0 {
+		ops = append(ops, WithMaxHeaderBytes(conf.MaxHeaderBytes))
+	}
+	return e.serve(e.newServer(conf.Address(), ops), conf.CertFile, conf.KeyFile)
+}
+
+// OnShutdown registers hooks that run after Shutdown has drained the
+// servers, e.g. to close a database or release a pool.
+func (e *Engine) OnShutdown(hooks ...func()) {
+	e.serverMu.Lock()
+	e.shutdownHooks = append(e.shutdownHooks, hooks...)
+	e.serverMu.Unlock()
+}
+
+// Shutdown stops accepting connections, waits for in-flight requests until
+// ctx is done and then runs the OnShutdown hooks. When ctx is done before the
+// requests are drained the error is returned without running the hooks, so
+// Shutdown can be called again.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.serverMu.Lock()
+	e.inShutdown = true
+	done := make(chan struct{})
+	defer close(done)
+	e.shutdownDone = done
+	servers := e.servers
+	e.serverMu.Unlock()
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	e.serverMu.Lock()
+	e.servers = nil
+	hooks := e.shutdownHooks
+	e.shutdownHooks = nil
+	e.serverMu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// ShutdownOnSignal calls Shutdown with the given timeout once one of signals
+// is received, SIGINT and SIGTERM are used when none are given.
+func (e *Engine) ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, signals...)
+	go func() {
+		<-quit
+		signal.Stop(quit)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := e.Shutdown(ctx); err != nil && e.Logger != nil {
+			e.Logger.Error(err)
+		}
+	}()
+}
@@ -0,0 +1,138 @@
+package zorm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// runSlowServer runs engine with a /slow route answering after delay and
+// returns its address and the result of Run.
+func runSlowServer(t *testing.T, engine *Engine, delay time.Duration) (string, chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	engine.Group("").Get("/slow", func(ctx *Context) {
+		time.Sleep(delay)
+		ctx.String(http.StatusOK, "done")
+	})
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- engine.Run(addr, WithReadTimeout(time.Second), WithMaxHeaderBytes(1<<16))
+	}()
+
+	var conn net.Conn
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr, runErr
+}
+
+func getSlow(addr string) chan string {
+	body := make(chan string, 1)
+	go func() {
+		rsp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer rsp.Body.Close()
+		data, _ := io.ReadAll(rsp.Body)
+		body <- string(data)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	return body
+}
+
+func TestShutdownDrainsRequests(t *testing.T) {
+	engine := New()
+	var hookCalled bool
+	engine.OnShutdown(func() {
+		hookCalled = true
+	})
+	addr, runErr := runSlowServer(t, engine, 200*time.Millisecond)
+	body := getSlow(addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q", got)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("Run returned %v", err)
+	}
+	if !hookCalled {
+		t.Error("shutdown hook was not called")
+	}
+}
+
+func TestRunWaitsForHooks(t *testing.T) {
+	engine := New()
+	hookDone := make(chan struct{})
+	engine.OnShutdown(func() {
+		time.Sleep(100 * time.Millisecond)
+		close(hookDone)
+	})
+	_, runErr := runSlowServer(t, engine, 0)
+	go engine.Shutdown(context.Background())
+	if err := <-runErr; err != nil {
+		t.Errorf("Run returned %v", err)
+	}
+	select {
+	case <-hookDone:
+	default:
+		t.Error("Run returned before the shutdown hook finished")
+	}
+}
+
+func TestShutdownTimeoutSkipsHooks(t *testing.T) {
+	engine := New()
+	var hookCalled bool
+	engine.OnShutdown(func() {
+		hookCalled = true
+	})
+	addr, runErr := runSlowServer(t, engine, 300*time.Millisecond)
+	body := getSlow(addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := engine.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown returned %v", err)
+	}
+	if hookCalled {
+		t.Error("shutdown hook ran before the requests were drained")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q", got)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("Run returned %v", err)
+	}
+	if !hookCalled {
+		t.Error("shutdown hook was not called")
+	}
+}
@@ -0,0 +1,123 @@
+package zorm
+
+import (
+	"github.com/caixr9527/zorm/render"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SSEBroker fans Server-Sent Events out to every subscribed client.
+//
+//	broker := zorm.NewSSEBroker(16)
+//	group.Get("/events", broker.Serve)
+//	broker.Publish(render.SSEvent{Event: "status", Data: order})
+type SSEBroker struct {
+	// KeepAlive sends a comment line at this interval so idle proxies
+	// don't drop the connection, 0 disables it.
+	KeepAlive time.Duration
+	mu        sync.RWMutex
+	clients   map[chan render.SSEvent]struct{}
+	buffer    int
+	closed    bool
+}
+
+// NewSSEBroker returns a broker buffering up to buffer events per client.
+func NewSSEBroker(buffer int) *SSEBroker {
+	if buffer < 0 {
+		buffer = 0
+	}
+	return &SSEBroker{
+		KeepAlive: 30 * time.Second,
+		clients:   make(map[chan render.SSEvent]struct{}),
+		buffer:    buffer,
+	}
+}
+
+// Subscribe returns the events published from now on and a cancel func
+// that unsubscribes and closes the channel.
+func (b *SSEBroker) Subscribe() (<-chan render.SSEvent, func()) {
+	ch := make(chan render.SSEvent, b.buffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.clients[ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.clients[ch]; ok {
+			delete(b.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends event to every client, a client whose buffer is full misses
+// it rather than holding up the others.
+func (b *SSEBroker) Publish(event render.SSEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.clients {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Clients returns the number of subscribed clients.
+func (b *SSEBroker) Clients() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.clients)
+}
+
+// Close ends the streams of every client, later subscribers get a closed
+// channel.
+func (b *SSEBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for ch := range b.clients {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+// Serve is a handler streaming the broker's events to the client until it
+// disconnects or the broker is closed.
+func (b *SSEBroker) Serve(ctx *Context) {
+	events, cancel := b.Subscribe()
+	defer cancel()
+	(&render.SSEvent{}).WriteContentType(ctx.W)
+	ctx.W.WriteHeader(http.StatusOK)
+	ctx.Flush()
+	var keepAlive <-chan time.Time
+	if b.KeepAlive > 0 {
+		ticker := time.NewTicker(b.KeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+	done := ctx.R.Context().Done()
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-done:
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			return ctx.Render(-1, &event) == nil
+		case <-keepAlive:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package zorm
+
+import (
+	"bufio"
+	"context"
+	"github.com/caixr9527/zorm/render"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEvent(t *testing.T) {
+	engine := New()
+	engine.Group("order").Get("/events", func(ctx *Context) {
+		ctx.SSEvent("status", map[string]any{"id": 1, "status": "paid"})
+		ctx.SSEvent("", "line1\nline2")
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/order/events", nil))
+	want := "event: status\ndata: {\"id\":1,\"status\":\"paid\"}\n\ndata: line1\ndata: line2\n\n"
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" || w.Body.String() != want {
+		t.Errorf("got %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if !w.Flushed {
+		t.Error("events were not flushed")
+	}
+}
+
+func TestStreamClientGone(t *testing.T) {
+	engine := New()
+	steps := 0
+	gone := false
+	engine.Group("order").Get("/stream", func(ctx *Context) {
+		gone = ctx.Stream(func(w io.Writer) bool {
+			steps++
+			io.WriteString(w, "tick\n")
+			return true
+		})
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/order/stream", nil).WithContext(ctx))
+	if !gone || steps != 0 {
+		t.Errorf("gone %v after %d steps", gone, steps)
+	}
+}
+
+func TestSSEBroker(t *testing.T) {
+	broker := NewSSEBroker(4)
+	engine := New()
+	engine.Group("order").Get("/events", broker.Serve)
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	var readers []*bufio.Reader
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(server.URL + "/order/events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("Content-Type %q", resp.Header.Get("Content-Type"))
+		}
+		readers = append(readers, bufio.NewReader(resp.Body))
+	}
+	for deadline := time.Now().Add(time.Second); broker.Clients() != 2; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d clients subscribed", broker.Clients())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	broker.Publish(render.SSEvent{Id: "1", Event: "status", Data: "paid"})
+	for _, r := range readers {
+		var lines []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line == "\n" {
+				break
+			}
+			lines = append(lines, line)
+		}
+		if got := strings.Join(lines, ""); got != "id: 1\nevent: status\ndata: paid\n" {
+			t.Errorf("got %q", got)
+		}
+	}
+
+	broker.Close()
+	for _, r := range readers {
+		if _, err := r.ReadString('\n'); err != io.EOF {
+			t.Errorf("stream not ended: %v", err)
+		}
+	}
+	if broker.Clients() != 0 {
+		t.Errorf("%d clients left", broker.Clients())
+	}
+}
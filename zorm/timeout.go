@@ -0,0 +1,188 @@
+package zorm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timeout cancels the request context after d and answers 503 when the
+// rest of the chain has not finished by then, e.g.
+//
+//	group.Get("/find", find, zorm.Timeout(3*time.Second))
+//
+// The handlers write to a buffer sent once they finish, so it is not meant
+// for routes that stream or upgrade the connection. Handlers should pass
+// ctx to database and RPC calls so they stop when it expires.
+func Timeout(d time.Duration) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			handlerCtx, cancel := context.WithCancel(ctx.R.Context())
+			defer cancel()
+			deadlineCtx := &deadlineContext{Context: handlerCtx, deadline: time.Now().Add(d)}
+			r, w := ctx.R, ctx.W
+			tw := &timeoutWriter{header: w.Header().Clone(), status: http.StatusOK, size: noWritten}
+			ctx.R = r.WithContext(deadlineCtx)
+			ctx.W = tw
+
+			done := make(chan struct{})
+			var p any
+			go func() {
+				defer close(done)
+				defer func() {
+					p = recover()
+				}()
+				next(ctx)
+			}()
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-done:
+				tw.writeTo(w)
+			case <-timer.C:
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+				writeTimeout(w)
+				// handlers only see Done once their writes are refused
+				deadlineCtx.expired.Store(true)
+				cancel()
+				// the pool must not reuse ctx while the handlers still run
+				<-done
+			}
+			ctx.R, ctx.W = r, w
+			if p != nil {
+				panic(p)
+			}
+		}
+	}
+}
+
+// writeTimeout sends the 503 right away instead of when the handlers
+// return. The Content-Length lets the client finish reading it and makes
+// net/http drop anything written after it.
+func writeTimeout(w ResponseWriter) {
+	body := http.StatusText(http.StatusServiceUnavailable) + "\n"
+	header := w.Header()
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.WriteString(body)
+	w.Flush()
+}
+
+// deadlineContext is cancelled by Timeout after it answered 503, unlike
+// context.WithTimeout whose Done could be seen by handlers first.
+type deadlineContext struct {
+	context.Context
+	deadline time.Time
+	expired  atomic.Bool
+}
+
+func (c *deadlineContext) Deadline() (time.Time, bool) {
+	if deadline, ok := c.Context.Deadline(); ok && deadline.Before(c.deadline) {
+		return deadline, true
+	}
+	return c.deadline, true
+}
+
+func (c *deadlineContext) Err() error {
+	err := c.Context.Err()
+	if err != nil && c.expired.Load() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// timeoutWriter buffers the response of the handlers run by Timeout,
+// writes after the timeout fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	size     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if code <= 0 || tw.timedOut || tw.size != noWritten {
+		return
+	}
+	tw.status = code
+	tw.size = 0
+}
+
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.size == noWritten {
+		tw.size = 0
+	}
+	n, err := tw.buf.Write(data)
+	tw.size += n
+	return n, err
+}
+
+func (tw *timeoutWriter) WriteString(s string) (int, error) {
+	return tw.Write([]byte(s))
+}
+
+func (tw *timeoutWriter) Status() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.status
+}
+
+func (tw *timeoutWriter) Size() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.size
+}
+
+func (tw *timeoutWriter) Written() bool {
+	return tw.Size() != noWritten
+}
+
+// Flush does nothing, the response is sent when the handlers finish.
+func (tw *timeoutWriter) Flush() {}
+
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+func (tw *timeoutWriter) Push(string, *http.PushOptions) error {
+	return http.ErrNotSupported
+}
+
+func (tw *timeoutWriter) writeTo(w ResponseWriter) {
+	header := w.Header()
+	for k := range header {
+		delete(header, k)
+	}
+	for k, v := range tw.header {
+		header[k] = v
+	}
+	if tw.size == noWritten {
+		return
+	}
+	w.WriteHeader(tw.status)
+	io.Copy(w, &tw.buf)
+}
@@ -0,0 +1,117 @@
+package zorm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestContextAsContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(parent)
+	ctx := &Context{R: r}
+	ctx.Set("user", "tom")
+	if ctx.Value(ctxKey{}) != "request" || ctx.Value("user") != "tom" || ctx.Value("missing") != nil {
+		t.Errorf("values %v %v", ctx.Value(ctxKey{}), ctx.Value("user"))
+	}
+	cancel()
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatal("not done after the request context was cancelled")
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("err %v", ctx.Err())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	engine := New()
+	engine.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			ctx.W.Header().Set("X-Outer", "1")
+			next(ctx)
+		}
+	})
+	group := engine.Group("t")
+	writeErr := make(chan error, 1)
+	group.Get("/slow", func(ctx *Context) {
+		<-ctx.Done()
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			t.Errorf("err %v", ctx.Err())
+		}
+		_, err := ctx.W.Write([]byte("late"))
+		writeErr <- err
+	}, Timeout(20*time.Millisecond))
+	group.Get("/fast", func(ctx *Context) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("no deadline")
+		}
+		ctx.W.Header().Set("X-Inner", "1")
+		ctx.String(http.StatusCreated, "ok")
+	}, Timeout(time.Second))
+	group.Get("/panic", func(ctx *Context) {
+		panic("boom")
+	}, Timeout(time.Second))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/t/slow", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "Service Unavailable\n" {
+		t.Errorf("slow: %d %q", w.Code, w.Body.String())
+	}
+	if err := <-writeErr; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("write after timeout: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/t/fast", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "ok" || w.Header().Get("X-Outer") != "1" || w.Header().Get("X-Inner") != "1" {
+		t.Errorf("fast: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("panic %v", p)
+		}
+	}()
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/t/panic", nil))
+}
+
+func TestTimeoutRespondsBeforeHandlerReturns(t *testing.T) {
+	engine := New()
+	release := make(chan struct{})
+	engine.Group("t").Get("/stuck", func(ctx *Context) {
+		// ignores ctx like a handler blocked in a call without it
+		select {
+		case <-release:
+		case <-time.After(2 * time.Second):
+		}
+		ctx.String(http.StatusOK, "late")
+	}, Timeout(50*time.Millisecond))
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	rsp, err := http.Get(srv.URL + "/t/stuck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("503 took %v", elapsed)
+	}
+	if rsp.StatusCode != http.StatusServiceUnavailable || string(body) != "Service Unavailable\n" {
+		t.Errorf("got %d %q", rsp.StatusCode, body)
+	}
+}
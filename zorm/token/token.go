@@ -223,8 +223,9 @@ func (j *JwtHandler) AuthInterceptor(next zorm.HandlerFunc) zorm.HandlerFunc {
 
 func (j *JwtHandler) AuthErrorHandler(ctx *zorm.Context, err error) {
 	if j.AuthHandler == nil {
-		ctx.W.WriteHeader(http.StatusUnauthorized)
+		ctx.AbortWithStatus(http.StatusUnauthorized)
 	} else {
 		j.AuthHandler(ctx, err)
+		ctx.Abort()
 	}
 }
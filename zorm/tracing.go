@@ -0,0 +1,43 @@
+package zorm
+
+import (
+	zormLog "github.com/caixr9527/zorm/log"
+	"github.com/caixr9527/zorm/tracing"
+	"net/http"
+)
+
+// TraceIDKey is the key of the trace ID in the fields of Context.Logger.
+const TraceIDKey = "trace_id"
+
+// Tracing starts a server span with the default tracer for each request,
+// continuing the trace of the traceparent header. The span is put in the
+// request context, so rpc clients and orm sessions given ctx add their
+// spans to the trace and pass it on.
+func Tracing(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		parent := tracing.Extract(ctx.R.Context(), ctx.R.Header)
+		name := ctx.R.Method
+		if ctx.FullPath() != "" {
+			name += " " + ctx.FullPath()
+		}
+		spanCtx, span := tracing.Start(parent, name, tracing.SpanKindServer)
+		defer span.End()
+		span.SetAttribute("http.method", ctx.R.Method)
+		span.SetAttribute("http.route", ctx.FullPath())
+		span.SetAttribute("http.target", ctx.R.URL.RequestURI())
+		span.SetAttribute("net.peer.ip", ctx.ClientIP())
+		if id := ctx.RequestID(); id != "" {
+			span.SetAttribute("http.request_id", id)
+		}
+		ctx.R = ctx.R.WithContext(spanCtx)
+		if ctx.Logger != nil {
+			ctx.Logger = ctx.Logger.WithFields(zormLog.Fields{TraceIDKey: span.SpanContext().TraceID.String()})
+		}
+		next(ctx)
+		status := ctx.W.Status()
+		span.SetAttribute("http.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+const (
+	maxTraceStateLen     = 512
+	maxTraceStateMembers = 32
+)
+
+var ErrInvalidTraceparent = errors.New("tracing: invalid traceparent")
+
+// Carrier reads and writes the propagation fields, http.Header is one.
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// MapCarrier is a Carrier over a map, e.g. the metadata of a TCP RPC
+// frame.
+type MapCarrier map[string]string
+
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Inject writes the span context of ctx into carrier, it writes nothing
+// when ctx has none.
+func Inject(ctx context.Context, carrier Carrier) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	carrier.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		carrier.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+// Extract returns ctx with the remote span context read from carrier, or
+// ctx itself when carrier has no valid traceparent.
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	sc, err := ParseTraceparent(carrier.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = parseTraceState(carrier.Get(TracestateHeader))
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Traceparent formats sc as a version 00 traceparent value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent value. Versions above 00 are read
+// as 00, ignoring the fields they add.
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	version, ok := decodeHex(s[:2])
+	if !ok || version[0] == 0xff {
+		return sc, ErrInvalidTraceparent
+	}
+	if version[0] == 0 && len(s) != 55 || len(s) > 55 && s[55] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	traceID, ok := decodeHex(s[3:35])
+	if !ok {
+		return sc, ErrInvalidTraceparent
+	}
+	spanID, ok := decodeHex(s[36:52])
+	if !ok {
+		return sc, ErrInvalidTraceparent
+	}
+	flags, ok := decodeHex(s[53:55])
+	if !ok {
+		return sc, ErrInvalidTraceparent
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Sampled = flags[0]&1 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex only, as traceparent requires.
+func decodeHex(s string) ([]byte, bool) {
+	if strings.ToLower(s) != s {
+		return nil, false
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+// parseTraceState keeps a tracestate to pass it on, it is dropped when it
+// is over the limits of the spec.
+func parseTraceState(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || len(s) > maxTraceStateLen {
+		return ""
+	}
+	members := 0
+	for _, member := range strings.Split(s, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		if key, _, ok := strings.Cut(member, "="); !ok || key == "" {
+			return ""
+		}
+		members++
+	}
+	if members > maxTraceStateMembers {
+		return ""
+	}
+	return s
+}
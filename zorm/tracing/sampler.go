@@ -0,0 +1,49 @@
+package tracing
+
+import "encoding/binary"
+
+// Sampler decides whether a new span is recorded, the decision is passed
+// on to the next services in the sampled flag.
+type Sampler interface {
+	ShouldSample(parent SpanContext, traceID TraceID) bool
+}
+
+type SamplerFunc func(parent SpanContext, traceID TraceID) bool
+
+func (f SamplerFunc) ShouldSample(parent SpanContext, traceID TraceID) bool {
+	return f(parent, traceID)
+}
+
+func AlwaysSample() Sampler {
+	return SamplerFunc(func(SpanContext, TraceID) bool { return true })
+}
+
+func NeverSample() Sampler {
+	return SamplerFunc(func(SpanContext, TraceID) bool { return false })
+}
+
+// TraceIDRatio samples the given fraction of traces. The decision depends
+// on the trace ID only, so all services agree on it.
+func TraceIDRatio(ratio float64) Sampler {
+	if ratio >= 1 {
+		return AlwaysSample()
+	}
+	if ratio <= 0 {
+		return NeverSample()
+	}
+	bound := uint64(ratio * (1 << 63))
+	return SamplerFunc(func(_ SpanContext, traceID TraceID) bool {
+		return binary.BigEndian.Uint64(traceID[8:])>>1 < bound
+	})
+}
+
+// ParentBased follows the decision of the parent span, root spans are
+// decided by root.
+func ParentBased(root Sampler) Sampler {
+	return SamplerFunc(func(parent SpanContext, traceID TraceID) bool {
+		if parent.IsValid() {
+			return parent.Sampled
+		}
+		return root.ShouldSample(parent, traceID)
+	})
+}
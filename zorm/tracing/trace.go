@@ -0,0 +1,122 @@
+// Package tracing records spans of the work done for a request and
+// carries their context between services in the W3C traceparent and
+// tracestate headers.
+//
+//	tracing.SetDefaultTracer(tracing.NewTracer("ordercenter",
+//		tracing.WithExporter(tracing.NewOTLPExporter(""))))
+//	ctx, span := tracing.Start(ctx, "load order", tracing.SpanKindInternal)
+//	defer span.End()
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	mathrand "math/rand"
+	"sync"
+)
+
+type TraceID [16]byte
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+type SpanID [8]byte
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is the part of a span passed to other services.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+	// Remote is set when the context was extracted from a request.
+	Remote bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span in ctx, or nil. The methods of Span
+// accept a nil receiver.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a copy of ctx whose next span is a
+// child of the remote sc.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the context of the span in ctx, or the
+// remote one extracted into ctx.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// ids are random but don't need crypto/rand for every span
+var (
+	idMu   sync.Mutex
+	idRand = func() *mathrand.Rand {
+		var seed [8]byte
+		rand.Read(seed[:])
+		return mathrand.New(mathrand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))))
+	}()
+)
+
+func newTraceID() TraceID {
+	idMu.Lock()
+	defer idMu.Unlock()
+	var t TraceID
+	for !t.IsValid() {
+		binary.BigEndian.PutUint64(t[:8], idRand.Uint64())
+		binary.BigEndian.PutUint64(t[8:], idRand.Uint64())
+	}
+	return t
+}
+
+func newSpanID() SpanID {
+	idMu.Lock()
+	defer idMu.Unlock()
+	var s SpanID
+	for !s.IsValid() {
+		binary.BigEndian.PutUint64(s[:], idRand.Uint64())
+	}
+	return s
+}
@@ -0,0 +1,253 @@
+package tracing
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBatchSize    = 512
+	defaultBatchTimeout = 5 * time.Second
+	defaultQueueSize    = 2048
+)
+
+// Tracer starts spans and exports the ended ones in batches. Spans are
+// dropped when the queue is full rather than slowing requests down.
+type Tracer struct {
+	service      string
+	sampler      Sampler
+	exporter     Exporter
+	batchSize    int
+	batchTimeout time.Duration
+	queue        chan SpanData
+	flush        chan chan error
+	stop         chan struct{}
+	stopped      chan struct{}
+	closeOnce    sync.Once
+}
+
+type TracerOption interface {
+	Apply(t *Tracer)
+}
+
+type DefaultTracerOption struct {
+	f func(t *Tracer)
+}
+
+func (d *DefaultTracerOption) Apply(t *Tracer) {
+	d.f(t)
+}
+
+// WithSampler sets the sampler, ParentBased(AlwaysSample()) by default.
+func WithSampler(sampler Sampler) TracerOption {
+	return &DefaultTracerOption{
+		f: func(t *Tracer) {
+			t.sampler = sampler
+		},
+	}
+}
+
+// WithExporter sets where spans go, without one spans are only propagated.
+func WithExporter(exporter Exporter) TracerOption {
+	return &DefaultTracerOption{
+		f: func(t *Tracer) {
+			t.exporter = exporter
+		},
+	}
+}
+
+// WithBatch sets the most spans exported at once and how long a span
+// waits for its batch.
+func WithBatch(size int, timeout time.Duration) TracerOption {
+	return &DefaultTracerOption{
+		f: func(t *Tracer) {
+			t.batchSize = size
+			t.batchTimeout = timeout
+		},
+	}
+}
+
+// NewTracer returns a tracer for service, call Shutdown before exiting so
+// the last spans are exported.
+func NewTracer(service string, ops ...TracerOption) *Tracer {
+	t := &Tracer{
+		service:      service,
+		sampler:      ParentBased(AlwaysSample()),
+		batchSize:    defaultBatchSize,
+		batchTimeout: defaultBatchTimeout,
+	}
+	for _, op := range ops {
+		op.Apply(t)
+	}
+	if t.batchSize <= 0 {
+		t.batchSize = defaultBatchSize
+	}
+	if t.batchTimeout <= 0 {
+		t.batchTimeout = defaultBatchTimeout
+	}
+	if t.exporter != nil {
+		t.queue = make(chan SpanData, defaultQueueSize)
+		t.flush = make(chan chan error)
+		t.stop = make(chan struct{})
+		t.stopped = make(chan struct{})
+		go t.run()
+	}
+	return t
+}
+
+// Start starts a span as a child of the span or remote span context in
+// ctx, it returns ctx with the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+	}
+	sc.Sampled = t.sampler.ShouldSample(parent, sc.TraceID)
+	span := &Span{
+		tracer:    t,
+		recording: sc.Sampled && t.exporter != nil,
+		data: SpanData{
+			Service:      t.service,
+			Name:         name,
+			Kind:         kind,
+			SpanContext:  sc,
+			ParentSpanID: parent.SpanID,
+			Start:        time.Now(),
+		},
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case <-t.stop:
+	case t.queue <- data:
+	default:
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	batch := make([]SpanData, 0, t.batchSize)
+	export := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err := t.exporter.Export(ctx, batch)
+		if err != nil {
+			log.Println("tracing: export:", err)
+		}
+		batch = make([]SpanData, 0, t.batchSize)
+		return err
+	}
+	// take the spans already queued before exporting on demand
+	drain := func() {
+		for {
+			select {
+			case data := <-t.queue:
+				batch = append(batch, data)
+			default:
+				return
+			}
+		}
+	}
+	ticker := time.NewTicker(t.batchTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.batchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case done := <-t.flush:
+			drain()
+			done <- export()
+		case <-t.stop:
+			drain()
+			export()
+			return
+		}
+	}
+}
+
+// Exporting reports whether t has an exporter, without one spans are
+// only propagated.
+func (t *Tracer) Exporting() bool {
+	return t.exporter != nil
+}
+
+// Flush exports the spans ended so far.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t.exporter == nil {
+		return nil
+	}
+	done := make(chan error, 1)
+	select {
+	case t.flush <- done:
+	case <-t.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown exports the remaining spans and shuts the exporter down, spans
+// ending afterwards are dropped.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.exporter == nil {
+		return nil
+	}
+	t.closeOnce.Do(func() {
+		close(t.stop)
+	})
+	select {
+	case <-t.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.exporter.Shutdown(ctx)
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+func init() {
+	defaultTracer.Store(NewTracer(""))
+}
+
+// SetDefaultTracer sets the tracer used by Start and by the
+// instrumentation of zorm, rpc and orm.
+func SetDefaultTracer(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// DefaultTracer returns the tracer set by SetDefaultTracer. Until then it
+// has no exporter: spans are propagated but not recorded.
+func DefaultTracer() *Tracer {
+	return defaultTracer.Load()
+}
+
+// Start starts a span with the default tracer.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	return DefaultTracer().Start(ctx, name, kind)
+}
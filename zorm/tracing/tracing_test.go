@@ -0,0 +1,184 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		sc, err := ParseTraceparent(tt.value)
+		if (err == nil) != tt.valid || sc.Sampled != tt.sampled {
+			t.Errorf("%q: got %+v %v", tt.value, sc, err)
+		}
+		if tt.valid && strings.HasPrefix(tt.value, "00") && sc.Traceparent() != tt.value {
+			t.Errorf("%q: formatted as %q", tt.value, sc.Traceparent())
+		}
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set(TracestateHeader, "congo=t61rcWkgMzE, rojo=00f067aa0ba902b7")
+	tracer := NewTracer("test")
+	ctx, span := tracer.Start(Extract(context.Background(), header), "child", SpanKindServer)
+	sc := span.SpanContext()
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() == "00f067aa0ba902b7" || !sc.Sampled {
+		t.Errorf("child %+v", sc)
+	}
+	carrier := MapCarrier{}
+	Inject(ctx, carrier)
+	if carrier[TraceparentHeader] != sc.Traceparent() || carrier[TracestateHeader] != "congo=t61rcWkgMzE, rojo=00f067aa0ba902b7" {
+		t.Errorf("injected %v", carrier)
+	}
+
+	header.Set(TraceparentHeader, "garbage")
+	if ctx := Extract(context.Background(), header); SpanContextFromContext(ctx).IsValid() {
+		t.Error("invalid traceparent extracted")
+	}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set(TracestateHeader, "no-equals-sign")
+	if sc := SpanContextFromContext(Extract(context.Background(), header)); sc.TraceState != "" {
+		t.Errorf("invalid tracestate kept: %q", sc.TraceState)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	exporter := NewMemoryExporter()
+	tracer := NewTracer("orders", WithExporter(exporter))
+	ctx, root := tracer.Start(context.Background(), "root", SpanKindServer)
+	_, child := tracer.Start(ctx, "child", SpanKindClient)
+	child.SetAttribute("rpc.method", "Find")
+	child.RecordError(context.DeadlineExceeded)
+	child.End()
+	child.SetAttribute("late", true)
+	root.End()
+	root.End()
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	got, parent := spans[0], spans[1]
+	if got.Name != "child" || got.ParentSpanID != parent.SpanContext.SpanID || got.SpanContext.TraceID != parent.SpanContext.TraceID ||
+		parent.ParentSpanID.IsValid() || got.Service != "orders" {
+		t.Errorf("got %+v, parent %+v", got, parent)
+	}
+	if got.Status != StatusError || got.Attributes["rpc.method"] != "Find" || got.Attributes["late"] != nil {
+		t.Errorf("child %+v", got)
+	}
+
+	exporter.Reset()
+	unsampled := NewTracer("orders", WithExporter(exporter), WithSampler(NeverSample()))
+	_, span := unsampled.Start(context.Background(), "dropped", SpanKindInternal)
+	span.End()
+	unsampled.Shutdown(context.Background())
+	if span.IsRecording() || !span.SpanContext().IsValid() || span.SpanContext().Sampled || len(exporter.Spans()) != 0 {
+		t.Errorf("unsampled span %+v", span.SpanContext())
+	}
+	// after shutdown spans are dropped
+	_, span = tracer.Start(context.Background(), "late", SpanKindInternal)
+	tracer.Shutdown(context.Background())
+	span.End()
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTraceIDRatio(t *testing.T) {
+	sampler := TraceIDRatio(0.25)
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		id := newTraceID()
+		decision := sampler.ShouldSample(SpanContext{}, id)
+		if decision != sampler.ShouldSample(SpanContext{}, id) {
+			t.Fatal("decision not stable")
+		}
+		if decision {
+			sampled++
+		}
+	}
+	if sampled < 2200 || sampled > 2800 {
+		t.Errorf("sampled %d of 10000", sampled)
+	}
+	parent := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	if !ParentBased(NeverSample()).ShouldSample(parent, parent.TraceID) {
+		t.Error("parent decision ignored")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan otlpTraces, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var traces otlpTraces
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&traces); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- traces
+	}))
+	defer collector.Close()
+
+	tracer := NewTracer("goods", WithExporter(NewOTLPExporter(collector.URL+"/v1/traces")))
+	_, span := tracer.Start(context.Background(), "GET /goods/find", SpanKindServer)
+	span.SetAttribute("http.status_code", 200)
+	span.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	traces := <-received
+	if len(traces.ResourceSpans) != 1 || len(traces.ResourceSpans[0].ScopeSpans[0].Spans) != 1 {
+		t.Fatalf("got %+v", traces)
+	}
+	resource := traces.ResourceSpans[0].Resource.Attributes[0]
+	got := traces.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if resource.Key != "service.name" || *resource.Value.StringValue != "goods" {
+		t.Errorf("resource %+v", resource)
+	}
+	if got.Name != "GET /goods/find" || got.Kind != SpanKindServer || got.TraceID != span.SpanContext().TraceID.String() ||
+		len(got.Attributes) != 1 || *got.Attributes[0].Value.IntValue != "200" {
+		t.Errorf("span %+v", got)
+	}
+}
+
+func TestStdoutExporter(t *testing.T) {
+	var sb strings.Builder
+	tracer := NewTracer("goods", WithExporter(NewStdoutExporter(&sb)))
+	_, span := tracer.Start(context.Background(), "find", SpanKindInternal)
+	span.End()
+	tracer.Shutdown(context.Background())
+	var line map[string]any
+	if err := json.Unmarshal([]byte(sb.String()), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["name"] != "find" || line["kind"] != "internal" || line["trace_id"] != span.SpanContext().TraceID.String() {
+		t.Errorf("got %s", sb.String())
+	}
+}
@@ -0,0 +1,86 @@
+package zorm
+
+import (
+	"context"
+	"github.com/caixr9527/zorm/gateway"
+	"github.com/caixr9527/zorm/tracing"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func useMemoryTracer(t *testing.T) (*tracing.Tracer, *tracing.MemoryExporter) {
+	exporter := tracing.NewMemoryExporter()
+	tracer := tracing.NewTracer("test", tracing.WithExporter(exporter))
+	previous := tracing.DefaultTracer()
+	tracing.SetDefaultTracer(tracer)
+	t.Cleanup(func() {
+		tracing.SetDefaultTracer(previous)
+		tracer.Shutdown(context.Background())
+	})
+	return tracer, exporter
+}
+
+func TestTracing(t *testing.T) {
+	tracer, exporter := useMemoryTracer(t)
+	engine := New()
+	engine.Use(Tracing)
+	var inner tracing.SpanContext
+	engine.Group("trace").Get("/user/:id", func(ctx *Context) {
+		inner = tracing.SpanContextFromContext(ctx)
+		ctx.String(http.StatusInternalServerError, "fail")
+	})
+	r := httptest.NewRequest(http.MethodGet, "/trace/user/1", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	tracer.Flush(context.Background())
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /trace/user/:id" || span.Kind != tracing.SpanKindServer ||
+		span.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.ParentSpanID.String() != "00f067aa0ba902b7" {
+		t.Errorf("span %+v", span)
+	}
+	if span.Status != tracing.StatusError || span.Attributes["http.status_code"] != http.StatusInternalServerError {
+		t.Errorf("status %v %v", span.Status, span.Attributes)
+	}
+	if inner != span.SpanContext {
+		t.Errorf("handler saw %+v", inner)
+	}
+}
+
+func TestGatewayTracing(t *testing.T) {
+	tracer, exporter := useMemoryTracer(t)
+	received := make(chan string, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("traceparent")
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.ParseUint(portStr, 10, 64)
+
+	engine := New()
+	engine.OpenGateway = true
+	engine.SetGatewayConfig([]gateway.GWConfig{{Name: "order", Path: "/order/**", Host: host, Port: port}})
+	r := httptest.NewRequest(http.MethodGet, "/order/find", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	forwarded := <-received
+	tracer.Flush(context.Background())
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	if spans[0].Name != "gateway order" || spans[0].ParentSpanID.String() != "00f067aa0ba902b7" ||
+		forwarded != spans[0].SpanContext.Traceparent() || spans[0].Attributes["http.status_code"] != http.StatusOK {
+		t.Errorf("forwarded %q, span %+v", forwarded, spans[0])
+	}
+}
@@ -1,23 +1,193 @@
 package zorm
 
 import (
-	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/caixr9527/zorm/internal/radix"
 )
 
 func TestTreeNode(t *testing.T) {
-	root := &treeNode{name: "/", children: make([]*treeNode, 0)}
-	root.Put("/user/get/:id")
-	root.Put("/user/create/hello")
-	root.Put("/user/create/aaa")
-	root.Put("/order/get/aaa")
-
-	node := root.Get("/user/get/1")
-	fmt.Println(node)
-	node = root.Get("/user/create/hello")
-	fmt.Println(node)
-	node = root.Get("/user/create/aaa")
-	fmt.Println(node)
-	node = root.Get("/order/get/aaa")
-	fmt.Println(node)
+	tree := &radix.Tree[string]{}
+	for _, route := range []string{"/user/get/:id", "/user/create/hello", "/user/create/aaa", "/order/get/aaa"} {
+		if err := tree.Add(route, route); err != nil {
+			t.Fatalf("add %s: %v", route, err)
+		}
+	}
+
+	tests := []struct {
+		path  string
+		route string
+	}{
+		{"/user/get/1", "/user/get/:id"},
+		{"/user/create/hello", "/user/create/hello"},
+		{"/user/create/aaa", "/user/create/aaa"},
+		{"/order/get/aaa", "/order/get/aaa"},
+	}
+	for _, tt := range tests {
+		var ps Params
+		route, ok := tree.Find(tt.path, &ps)
+		if !ok || route != tt.route {
+			t.Errorf("%s: matched %q, want %q", tt.path, route, tt.route)
+		}
+	}
+	var ps Params
+	if _, ok := tree.Find("/order/get/bbb", &ps); ok {
+		t.Error("/order/get/bbb: unexpected match")
+	}
+}
+
+func TestTreeNodeParams(t *testing.T) {
+	tree := &radix.Tree[string]{}
+	for _, route := range []string{"/user/get/:id", "/user/*/info", "/static/**"} {
+		if err := tree.Add(route, route); err != nil {
+			t.Fatalf("add %s: %v", route, err)
+		}
+	}
+
+	tests := []struct {
+		path  string
+		key   string
+		value string
+	}{
+		{"/user/get/100", "id", "100"},
+		{"/user/abc/info", "*", "abc"},
+		{"/static/css/main.css", "**", "css/main.css"},
+	}
+	for _, tt := range tests {
+		var ps Params
+		if _, ok := tree.Find(tt.path, &ps); !ok {
+			t.Fatalf("%s: route not found", tt.path)
+		}
+		if got := ps.ByName(tt.key); got != tt.value {
+			t.Errorf("%s: param %s = %q, want %q", tt.path, tt.key, got, tt.value)
+		}
+	}
+}
+
+// legacyTreeNode is the segment tree the router used before the radix tree,
+// it is kept to benchmark against.
+type legacyTreeNode struct {
+	name       string
+	children   []*legacyTreeNode
+	routerName string
+	isEnd      bool
+}
+
+func (t *legacyTreeNode) Put(path string) {
+	strs := strings.Split(path, "/")
+	for index, name := range strs {
+		if index == 0 {
+			continue
+		}
+		isMatch := false
+		for _, node := range t.children {
+			if node.name == name {
+				isMatch = true
+				t = node
+				break
+			}
+		}
+		if !isMatch {
+			node := &legacyTreeNode{name: name, isEnd: index == len(strs)-1}
+			t.children = append(t.children, node)
+			t = node
+		}
+	}
+}
+
+func (t *legacyTreeNode) Get(path string) *legacyTreeNode {
+	strs := strings.Split(path, "/")
+	routerName := ""
+	for index, name := range strs {
+		if index == 0 {
+			continue
+		}
+		isMatch := false
+		for _, node := range t.children {
+			if node.name == name || node.name == "*" || strings.Contains(node.name, ":") {
+				isMatch = true
+				routerName += "/" + node.name
+				node.routerName = routerName
+				t = node
+				if index == len(strs)-1 {
+					return node
+				}
+				break
+			}
+		}
+		if !isMatch {
+			for _, node := range t.children {
+				if node.name == "**" {
+					routerName += "/" + node.name
+					node.routerName = routerName
+					return node
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var benchRoutes = []string{
+	"/user/hello",
+	"/user/get/:id",
+	"/user/g/*/get",
+	"/user/hello/get",
+	"/user/hello2",
+	"/user/html",
+	"/user/htmlTemplate",
+	"/user/login",
+	"/user/json",
+	"/user/xml",
+	"/user/excel",
+	"/user/redirect",
+	"/user/queryMap",
+	"/user/formPost",
+	"/user/jsonParam",
+	"/user/loginToken",
+	"/order/find",
+	"/order/findGrpc",
+	"/order/findTcp",
+	"/goods/find",
+	"/static/**",
+}
+
+var benchPaths = []string{
+	"/user/hello",
+	"/user/get/100",
+	"/user/g/abc/get",
+	"/user/loginToken",
+	"/order/findTcp",
+	"/static/css/main.css",
+}
+
+func BenchmarkLegacyTreeNode(b *testing.B) {
+	root := &legacyTreeNode{name: "/"}
+	for _, route := range benchRoutes {
+		root.Put(route)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range benchPaths {
+			root.Get(path)
+		}
+	}
+}
+
+func BenchmarkRadixTree(b *testing.B) {
+	tree := &radix.Tree[string]{}
+	for _, route := range benchRoutes {
+		_ = tree.Add(route, route)
+	}
+	ps := make(Params, 0, tree.MaxParams())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range benchPaths {
+			ps = ps[:0]
+			tree.Find(path, &ps)
+		}
+	}
 }
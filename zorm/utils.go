@@ -1,6 +1,9 @@
 package zorm
 
 import (
+	"path"
+	"reflect"
+	"runtime"
 	"strings"
 	"unicode"
 	"unsafe"
@@ -31,3 +34,34 @@ func StringToBytes(s string) []byte {
 		}{s, len(s)},
 	))
 }
+
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
+// cleanPath resolves "." and ".." elements and removes repeated slashes,
+// a trailing slash is kept.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	cleaned := path.Clean(p)
+	if p[len(p)-1] == '/' && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
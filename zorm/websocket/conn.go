@@ -0,0 +1,460 @@
+// Package websocket implements the WebSocket protocol of RFC 6455.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types, the values are the frame opcodes.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// Close codes of RFC 6455 section 7.4.1.
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+// DefaultReadLimit is the largest message a Conn reads unless
+// SetReadLimit changes it.
+const DefaultReadLimit = 1 << 20
+
+const maxControlPayload = 125
+
+var (
+	ErrReadLimit = errors.New("websocket: message exceeds read limit")
+	ErrCloseSent = errors.New("websocket: close sent")
+)
+
+// CloseError is returned by ReadMessage once the peer closed the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// IsCloseError reports whether err is a CloseError with one of codes.
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn is a WebSocket connection. One goroutine may read while others
+// write, writes are serialized.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	server      bool
+	subprotocol string
+	readLimit   int64
+	readErr     error
+	pingHandler func(data []byte) error
+	pongHandler func(data []byte) error
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, server bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	c := &Conn{
+		conn:      conn,
+		br:        br,
+		server:    server,
+		readLimit: DefaultReadLimit,
+	}
+	c.pingHandler = func(data []byte) error {
+		return c.writeFrame(true, PongMessage, data, time.Now().Add(time.Second))
+	}
+	c.pongHandler = func([]byte) error { return nil }
+	return c
+}
+
+// Subprotocol returns the subprotocol agreed in the handshake.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// SetReadLimit sets the largest message ReadMessage accepts, larger
+// messages close the connection with CloseMessageTooBig. A limit of 0 or
+// less sets DefaultReadLimit.
+func (c *Conn) SetReadLimit(limit int64) {
+	if limit <= 0 {
+		limit = DefaultReadLimit
+	}
+	c.readLimit = limit
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetPingHandler replaces the default reply to pings, which sends a pong
+// with the same data. h runs on the reading goroutine.
+func (c *Conn) SetPingHandler(h func(data []byte) error) {
+	if h == nil {
+		h = func([]byte) error { return nil }
+	}
+	c.pingHandler = h
+}
+
+// SetPongHandler sets the func called with the data of each pong, it runs
+// on the reading goroutine.
+func (c *Conn) SetPongHandler(h func(data []byte) error) {
+	if h == nil {
+		h = func([]byte) error { return nil }
+	}
+	c.pongHandler = h
+}
+
+// ReadMessage returns the next text or binary message, joining fragments
+// and answering control frames on the way. Once the peer closes it
+// returns a *CloseError.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	messageType, data, err = c.readMessage()
+	if err != nil {
+		c.readErr = err
+	}
+	return messageType, data, err
+}
+
+func (c *Conn) readMessage() (int, []byte, error) {
+	messageType := 0
+	var data []byte
+	for {
+		fin, opcode, payload, err := c.readFrame(int64(len(data)))
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case PingMessage:
+			if err := c.pingHandler(payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if err := c.pongHandler(payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(payload)
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "continuation frame without a message")
+			}
+		default:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "new message before the last one ended")
+			}
+			messageType = opcode
+		}
+		data = append(data, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, c.fail(CloseInvalidFramePayloadData, "invalid UTF-8 in text message")
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// readFrame reads one frame, read is the size of the message so far.
+func (c *Conn) readFrame(read int64) (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, c.abnormal(err)
+	}
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0f)
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set without an extension")
+	}
+	switch opcode {
+	case continuationFrame, TextMessage, BinaryMessage:
+	case CloseMessage, PingMessage, PongMessage:
+		if !fin {
+			return false, 0, nil, c.fail(CloseProtocolError, "fragmented control frame")
+		}
+	default:
+		return false, 0, nil, c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %d", opcode))
+	}
+	masked := header[1]&0x80 != 0
+	if masked != c.server {
+		return false, 0, nil, c.fail(CloseProtocolError, "wrong frame masking")
+	}
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, c.abnormal(err)
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, c.abnormal(err)
+		}
+		n := binary.BigEndian.Uint64(ext[:])
+		if n>>63 != 0 {
+			return false, 0, nil, c.fail(CloseProtocolError, "invalid payload length")
+		}
+		length = int64(n)
+	}
+	if opcode >= CloseMessage && length > maxControlPayload {
+		return false, 0, nil, c.fail(CloseProtocolError, "control frame too long")
+	}
+	if opcode < CloseMessage && c.readLimit > 0 && read+length > c.readLimit {
+		c.fail(CloseMessageTooBig, "message too big")
+		return false, 0, nil, ErrReadLimit
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, c.abnormal(err)
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, c.abnormal(err)
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *Conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "invalid close payload")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !validCloseCode(closeErr.Code) {
+			return c.fail(CloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(closeErr.Text) {
+			return c.fail(CloseInvalidFramePayloadData, "invalid UTF-8 in close reason")
+		}
+	}
+	// echo the code to complete the handshake
+	var reply []byte
+	if closeErr.Code != CloseNoStatusReceived {
+		reply = FormatCloseMessage(closeErr.Code, "")
+	}
+	c.writeFrame(true, CloseMessage, reply, time.Now().Add(time.Second))
+	return closeErr
+}
+
+// fail closes the connection with code, as the protocol requires after an
+// invalid frame.
+func (c *Conn) fail(code int, text string) error {
+	c.writeFrame(true, CloseMessage, FormatCloseMessage(code, text), time.Now().Add(time.Second))
+	c.conn.Close()
+	return &CloseError{Code: code, Text: text}
+}
+
+// abnormal turns a read error of the connection into CloseAbnormalClosure,
+// a timeout is returned as it is.
+func (c *Conn) abnormal(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return err
+	}
+	return &CloseError{Code: CloseAbnormalClosure, Text: err.Error()}
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// FormatCloseMessage returns the payload of a close frame.
+func FormatCloseMessage(code int, text string) []byte {
+	if code == CloseNoStatusReceived {
+		return nil
+	}
+	if len(text) > maxControlPayload-2 {
+		text = text[:maxControlPayload-2]
+	}
+	buf := make([]byte, 2+len(text))
+	binary.BigEndian.PutUint16(buf, uint16(code))
+	copy(buf[2:], text)
+	return buf
+}
+
+// ReadJSON reads the next message and decodes it into v.
+func (c *Conn) ReadJSON(v any) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage sends data as one frame. Sending CloseMessage starts the
+// close handshake, later writes fail with ErrCloseSent.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case CloseMessage, PingMessage, PongMessage:
+		if len(data) > maxControlPayload {
+			return errors.New("websocket: control frame too long")
+		}
+	default:
+		return fmt.Errorf("websocket: unknown message type %d", messageType)
+	}
+	return c.writeFrame(true, messageType, data, time.Time{})
+}
+
+// WriteJSON sends v encoded as JSON in a text message.
+func (c *Conn) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// Ping sends a ping, the answer reaches the pong handler.
+func (c *Conn) Ping(data []byte) error {
+	return c.WriteMessage(PingMessage, data)
+}
+
+// writeFrame writes one frame, deadline applies to this write only when
+// it is not zero.
+func (c *Conn) writeFrame(fin bool, opcode int, data []byte, deadline time.Time) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+	frame := make([]byte, 0, len(data)+14)
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	frame = append(frame, b0)
+	var maskBit byte
+	if !c.server {
+		maskBit = 0x80
+	}
+	switch n := len(data); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if c.server {
+		frame = append(frame, data...)
+	} else {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, data...)
+		maskBytes(mask, frame[start:])
+	}
+	if !deadline.IsZero() {
+		c.conn.SetWriteDeadline(deadline)
+		defer c.conn.SetWriteDeadline(time.Time{})
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Close sends a normal closure, if no close was sent yet, and closes the
+// connection.
+func (c *Conn) Close() error {
+	return c.CloseWithStatus(CloseNormalClosure, "")
+}
+
+// CloseWithStatus sends a close frame with code and text, if no close was
+// sent yet, and closes the connection.
+func (c *Conn) CloseWithStatus(code int, text string) error {
+	err := c.writeFrame(true, CloseMessage, FormatCloseMessage(code, text), time.Now().Add(time.Second))
+	if errors.Is(err, ErrCloseSent) || errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	if closeErr := c.conn.Close(); err == nil && !errors.Is(closeErr, net.ErrClosed) {
+		err = closeErr
+	}
+	return err
+}
+
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i&3]
+	}
+}
@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// Hub groups connections into rooms and broadcasts messages to them.
+// A connection whose write fails is removed from every room and closed.
+type Hub struct {
+	// WriteTimeout bounds each write of a broadcast so a slow client can't
+	// hold up the others, 0 means no timeout.
+	WriteTimeout time.Duration
+	mu           sync.RWMutex
+	rooms        map[string]map[*Conn]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		WriteTimeout: 10 * time.Second,
+		rooms:        make(map[string]map[*Conn]struct{}),
+	}
+}
+
+func (h *Hub) Join(room string, c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conns, ok := h.rooms[room]
+	if !ok {
+		conns = make(map[*Conn]struct{})
+		h.rooms[room] = conns
+	}
+	conns[c] = struct{}{}
+}
+
+func (h *Hub) Leave(room string, c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(room, c)
+}
+
+// LeaveAll removes c from every room, call it when the connection ends.
+func (h *Hub) LeaveAll(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for room := range h.rooms {
+		h.leave(room, c)
+	}
+}
+
+func (h *Hub) leave(room string, c *Conn) {
+	conns, ok := h.rooms[room]
+	if !ok {
+		return
+	}
+	delete(conns, c)
+	if len(conns) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Count returns the number of connections in room.
+func (h *Hub) Count(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Broadcast sends the message to every connection in room except those in
+// exclude, it returns the number of connections that received it. Only text
+// and binary messages can be broadcast, other types send nothing.
+func (h *Hub) Broadcast(room string, messageType int, data []byte, exclude ...*Conn) int {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return 0
+	}
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.rooms[room]))
+	for c := range h.rooms[room] {
+		conns = append(conns, c)
+	}
+	h.mu.RUnlock()
+
+	sent := 0
+	for _, c := range conns {
+		if contains(exclude, c) {
+			continue
+		}
+		var deadline time.Time
+		if h.WriteTimeout > 0 {
+			deadline = time.Now().Add(h.WriteTimeout)
+		}
+		if err := c.writeFrame(true, messageType, data, deadline); err != nil {
+			h.LeaveAll(c)
+			c.conn.Close()
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+func contains(conns []*Conn, c *Conn) bool {
+	for _, conn := range conns {
+		if conn == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HandshakeError is returned when a request is not a valid WebSocket
+// handshake, the HTTP error has already been answered.
+type HandshakeError struct {
+	Status  int
+	Message string
+}
+
+func (e *HandshakeError) Error() string {
+	return "websocket: " + e.Message
+}
+
+// Upgrader turns HTTP requests into WebSocket connections.
+type Upgrader struct {
+	// ReadLimit is the largest message a Conn accepts, DefaultReadLimit
+	// when it is 0 or negative.
+	ReadLimit int64
+	// Subprotocols lists the supported subprotocols in order of preference.
+	Subprotocols []string
+	// CheckOrigin reports whether the Origin header is allowed, nil allows
+	// requests without Origin and those from the same host.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Upgrade completes the handshake and hijacks the connection. On failure
+// it answers the HTTP error itself and returns a *HandshakeError.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, u.error(w, http.StatusMethodNotAllowed, "handshake method is not GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, u.error(w, http.StatusBadRequest, "not a websocket handshake")
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		w.Header().Set("Sec-Websocket-Version", "13")
+		return nil, u.error(w, http.StatusUpgradeRequired, "unsupported version")
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, u.error(w, http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, u.error(w, http.StatusForbidden, "origin not allowed")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, u.error(w, http.StatusInternalServerError, "response does not implement http.Hijacker")
+	}
+	subprotocol := u.subprotocol(r)
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	for k, values := range responseHeader {
+		if k == "Sec-Websocket-Protocol" {
+			continue
+		}
+		for _, v := range values {
+			b.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+	netConn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := netConn.Write([]byte(b.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetWriteDeadline(time.Time{})
+
+	// frames the client sent right after the handshake may already sit in
+	// the server's read buffer
+	c := newConn(netConn, brw.Reader, true)
+	c.subprotocol = subprotocol
+	if u.ReadLimit > 0 {
+		c.readLimit = u.ReadLimit
+	}
+	return c, nil
+}
+
+func (u *Upgrader) error(w http.ResponseWriter, status int, message string) error {
+	http.Error(w, http.StatusText(status), status)
+	return &HandshakeError{Status: status, Message: message}
+}
+
+func (u *Upgrader) subprotocol(r *http.Request) string {
+	requested := headerTokens(r.Header, "Sec-Websocket-Protocol")
+	for _, supported := range u.Subprotocols {
+		for _, p := range requested {
+			if p == supported {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// IsWebSocketUpgrade reports whether r asks for a WebSocket handshake.
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+func headerContains(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dial opens a client connection to a ws:// or wss:// URL.
+func Dial(rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	var netConn net.Conn
+	host := u.Host
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+		netConn, err = net.DialTimeout("tcp", host, 10*time.Second)
+	case "wss":
+		u.Scheme = "https"
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+		netConn, err = tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    u,
+		Host:   u.Host,
+		Header: make(http.Header),
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	netConn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContains(resp.Header, "Upgrade", "websocket") ||
+		resp.Header.Get("Sec-Websocket-Accept") != acceptKey(key) {
+		netConn.Close()
+		return nil, resp, errors.New("websocket: bad handshake")
+	}
+	netConn.SetDeadline(time.Time{})
+	c := newConn(netConn, br, false)
+	c.subprotocol = resp.Header.Get("Sec-Websocket-Protocol")
+	return c, resp, nil
+}
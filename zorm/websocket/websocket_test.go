@@ -0,0 +1,273 @@
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func echoServer(t *testing.T, u *Upgrader) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := u.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			messageType, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := c.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dial(t *testing.T, server *httptest.Server, header http.Header) *Conn {
+	t.Helper()
+	c, _, err := Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return c
+}
+
+func TestEcho(t *testing.T) {
+	c := dial(t, echoServer(t, &Upgrader{}), nil)
+	messages := []struct {
+		messageType int
+		data        []byte
+	}{
+		{TextMessage, []byte("hello")},
+		{BinaryMessage, []byte{0, 1, 2, 255}},
+		{TextMessage, bytes.Repeat([]byte("a"), 200)},
+		{BinaryMessage, bytes.Repeat([]byte("b"), 70000)},
+		{TextMessage, []byte{}},
+	}
+	for _, m := range messages {
+		if err := c.WriteMessage(m.messageType, m.data); err != nil {
+			t.Fatal(err)
+		}
+		messageType, data, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if messageType != m.messageType || !bytes.Equal(data, m.data) {
+			t.Errorf("got type %d with %d bytes, want type %d with %d bytes", messageType, len(data), m.messageType, len(m.data))
+		}
+	}
+}
+
+func TestFragmentsAndPing(t *testing.T) {
+	c := dial(t, echoServer(t, &Upgrader{}), nil)
+	pong := make(chan string, 1)
+	c.SetPongHandler(func(data []byte) error {
+		pong <- string(data)
+		return nil
+	})
+	// a ping may come between the fragments of a message
+	frames := []struct {
+		fin    bool
+		opcode int
+		data   string
+	}{
+		{false, TextMessage, "你"},
+		{false, continuationFrame, "好"[:1]},
+		{true, PingMessage, "ping"},
+		{true, continuationFrame, "好"[1:]},
+	}
+	for _, f := range frames {
+		if err := c.writeFrame(f.fin, f.opcode, []byte(f.data), time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	messageType, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messageType != TextMessage || string(data) != "你好" {
+		t.Errorf("got %d %q", messageType, data)
+	}
+	if got := <-pong; got != "ping" {
+		t.Errorf("pong %q", got)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		fin    bool
+		opcode int
+		data   []byte
+		code   int
+	}{
+		{"continuation without message", true, continuationFrame, []byte("x"), CloseProtocolError},
+		{"fragmented ping", false, PingMessage, nil, CloseProtocolError},
+		{"unknown opcode", true, 3, nil, CloseProtocolError},
+		{"invalid utf-8", true, TextMessage, []byte{0xff, 0xfe}, CloseInvalidFramePayloadData},
+		{"too big", true, BinaryMessage, make([]byte, 11), CloseMessageTooBig},
+	}
+	server := echoServer(t, &Upgrader{ReadLimit: 10})
+	for _, tt := range tests {
+		c := dial(t, server, nil)
+		if err := c.writeFrame(tt.fin, tt.opcode, tt.data, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err := c.ReadMessage()
+		if !IsCloseError(err, tt.code) {
+			t.Errorf("%s: got %v, want close %d", tt.name, err, tt.code)
+		}
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	for _, limit := range []int64{0, -1} {
+		server := echoServer(t, &Upgrader{ReadLimit: limit})
+		c := dial(t, server, nil)
+		c.SetReadLimit(limit)
+		if c.readLimit != DefaultReadLimit {
+			t.Errorf("SetReadLimit(%d) = %d", limit, c.readLimit)
+		}
+		if err := c.WriteMessage(BinaryMessage, make([]byte, DefaultReadLimit+1)); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := c.ReadMessage(); !IsCloseError(err, CloseMessageTooBig) {
+			t.Errorf("ReadLimit %d: got %v, want close %d", limit, err, CloseMessageTooBig)
+		}
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	c := dial(t, echoServer(t, &Upgrader{}), nil)
+	if err := c.WriteMessage(CloseMessage, FormatCloseMessage(CloseGoingAway, "bye")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteMessage(TextMessage, []byte("late")); !errors.Is(err, ErrCloseSent) {
+		t.Errorf("write after close: %v", err)
+	}
+	// the server echoes the code
+	if _, _, err := c.ReadMessage(); !IsCloseError(err, CloseGoingAway) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	server := echoServer(t, &Upgrader{
+		Subprotocols: []string{"v2", "v1"},
+		CheckOrigin: func(r *http.Request) bool {
+			return r.Header.Get("Origin") != "http://evil.com"
+		},
+	})
+	header := http.Header{"Sec-Websocket-Protocol": {"v1, v2"}}
+	if c := dial(t, server, header); c.Subprotocol() != "v2" {
+		t.Errorf("subprotocol %q", c.Subprotocol())
+	}
+
+	_, resp, err := Dial("ws"+strings.TrimPrefix(server.URL, "http"), http.Header{"Origin": {"http://evil.com"}})
+	if err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("origin: %v", err)
+	}
+	resp, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("plain GET: %d", resp.StatusCode)
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://example.com", true},
+		{"https://EXAMPLE.com", true},
+		{"http://example.com:8080", false},
+		{"http://other.com", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := sameOrigin(r); got != tt.want {
+			t.Errorf("%q: got %v", tt.origin, got)
+		}
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	joined := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := (&Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		hub.Join(r.URL.Query().Get("room"), c)
+		defer hub.LeaveAll(c)
+		joined <- struct{}{}
+		for {
+			_, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			hub.Broadcast(r.URL.Query().Get("room"), TextMessage, data, c)
+		}
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	var conns []*Conn
+	for _, room := range []string{"a", "a", "a", "b"} {
+		c, _, err := Dial(url+"?room="+room, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		<-joined
+		conns = append(conns, c)
+	}
+	if hub.Count("a") != 3 || hub.Count("b") != 1 {
+		t.Fatalf("rooms a=%d b=%d", hub.Count("a"), hub.Count("b"))
+	}
+	conns[0].WriteMessage(TextMessage, []byte("hi a"))
+	for _, c := range conns[1:3] {
+		if _, data, err := c.ReadMessage(); err != nil || string(data) != "hi a" {
+			t.Errorf("got %q %v", data, err)
+		}
+	}
+	for _, messageType := range []int{CloseMessage, PingMessage, PongMessage, continuationFrame, 3} {
+		if n := hub.Broadcast("b", messageType, nil); n != 0 {
+			t.Errorf("message type %d sent to %d", messageType, n)
+		}
+	}
+	if n := hub.Broadcast("b", BinaryMessage, []byte("hi b")); n != 1 {
+		t.Errorf("sent to %d", n)
+	}
+	if _, data, err := conns[3].ReadMessage(); err != nil || string(data) != "hi b" {
+		t.Errorf("got %q %v", data, err)
+	}
+	conns[3].Close()
+	for deadline := time.Now().Add(time.Second); hub.Count("b") != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("closed connection still in room b")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
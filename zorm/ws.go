@@ -0,0 +1,38 @@
+package zorm
+
+import (
+	"github.com/caixr9527/zorm/websocket"
+	"net/http"
+)
+
+// WSHandlerFunc handles a WebSocket connection, the connection is closed
+// when it returns.
+type WSHandlerFunc func(ctx *Context, conn *websocket.Conn)
+
+// Upgrade turns the request into a WebSocket connection using
+// Engine.Upgrader. When the handshake fails the HTTP error has already
+// been answered.
+func (c *Context) Upgrade() (*websocket.Conn, error) {
+	conn, err := c.engine.Upgrader.Upgrade(c.W, c.R, nil)
+	if err != nil {
+		return nil, err
+	}
+	// the handshake was written to the hijacked connection, only the
+	// status is recorded for the middlewares
+	c.W.WriteHeader(http.StatusSwitchingProtocols)
+	return conn, nil
+}
+
+// WS adds a GET route accepting WebSocket connections, the group's
+// middlewares and middlewareFunc run before the handshake so they can
+// refuse it, e.g. with JWT auth.
+func (r *routerGroup) WS(name string, handler WSHandlerFunc, middlewareFunc ...MiddlewareFunc) {
+	r.handle(name, http.MethodGet, func(ctx *Context) {
+		conn, err := ctx.Upgrade()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handler(ctx, conn)
+	}, middlewareFunc...)
+}
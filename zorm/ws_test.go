@@ -0,0 +1,81 @@
+package zorm
+
+import (
+	"github.com/caixr9527/zorm/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWS(t *testing.T) {
+	engine := New()
+	statuses := make(chan int, 2)
+	record := func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			w := &statusRecorder{ResponseWriter: ctx.W}
+			ctx.W = w
+			next(ctx)
+			if w.status != ctx.W.Status() {
+				t.Errorf("recorded status %d, writer status %d", w.status, ctx.W.Status())
+			}
+			statuses <- ctx.W.Status()
+		}
+	}
+	auth := func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			if ctx.GetHeader("Authorization") != "secret" {
+				ctx.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+			ctx.Set("user", "tom")
+			next(ctx)
+		}
+	}
+	engine.Group("chat").WS("/room", func(ctx *Context, conn *websocket.Conn) {
+		user, _ := ctx.Get("user")
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(user.(string)+": "+string(data)))
+		}
+	}, auth, record)
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/chat/room"
+
+	_, resp, err := websocket.Dial(url, nil)
+	if err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("without auth: %v", err)
+	}
+	if status := <-statuses; status != http.StatusUnauthorized {
+		t.Errorf("logged status %d", status)
+	}
+
+	conn, _, err := websocket.Dial(url, http.Header{"Authorization": {"secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "tom: hello" {
+		t.Errorf("got %q %v", data, err)
+	}
+	conn.Close()
+	if status := <-statuses; status != http.StatusSwitchingProtocols {
+		t.Errorf("logged status %d", status)
+	}
+}
+
+type statusRecorder struct {
+	ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
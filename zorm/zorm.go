@@ -3,15 +3,21 @@ package zorm
 import (
 	"errors"
 	"fmt"
+	"github.com/caixr9527/zorm/binding"
 	"github.com/caixr9527/zorm/config"
 	"github.com/caixr9527/zorm/gateway"
+	"github.com/caixr9527/zorm/internal/radix"
 	zormlog "github.com/caixr9527/zorm/log"
 	"github.com/caixr9527/zorm/render"
+	"github.com/caixr9527/zorm/requestid"
+	"github.com/caixr9527/zorm/tracing"
+	"github.com/caixr9527/zorm/websocket"
 	"html/template"
-	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -20,36 +26,73 @@ const ANY = "ANY"
 
 type HandlerFunc func(ctx *Context)
 
+type HandlersChain []HandlerFunc
+
 type MiddlewareFunc func(handlerFunc HandlerFunc) HandlerFunc
 
+func nextHandler(ctx *Context) {
+	ctx.Next()
+}
+
+// handler adapts a MiddlewareFunc to a chain element. Calling next continues
+// the chain, returning without calling next aborts it. m is called for every
+// request, as it was when middlewares wrapped the handler directly.
+func (m MiddlewareFunc) handler() HandlerFunc {
+	return func(ctx *Context) {
+		index := ctx.index
+		m(nextHandler)(ctx)
+		if ctx.index == index {
+			ctx.Abort()
+		}
+	}
+}
+
+// combineHandlers builds a chain running middlewares followed by handlers.
+// Each middleware wraps the ones added before it, so the last one added runs
+// first.
+func combineHandlers(middlewares []MiddlewareFunc, handlers ...HandlerFunc) HandlersChain {
+	chain := make(HandlersChain, 0, len(middlewares)+len(handlers))
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		chain = append(chain, middlewares[i].handler())
+	}
+	return append(chain, handlers...)
+}
+
 type routerGroup struct {
 	name               string
+	prefix             string
+	router             *router
 	handlerFuncMap     map[string]map[string]HandlerFunc
 	middlewaresFuncMap map[string]map[string][]MiddlewareFunc
 	handlerMethodMap   map[string][]string
-	treeNode           *treeNode
 	middlewares        []MiddlewareFunc
+	routes             []*route
+	parent             *routerGroup
+}
+
+// Group creates a child group, it inherits the middlewares of r including
+// the ones added to r after the call.
+func (r *routerGroup) Group(name string) *routerGroup {
+	return r.router.newGroup(name, joinPaths(r.prefix, name), r)
 }
 
 func (r *routerGroup) Use(middlewareFunc ...MiddlewareFunc) {
 	r.middlewares = append(r.middlewares, middlewareFunc...)
+	r.router.compile()
 }
 
-func (r *routerGroup) methodHandle(name string, method string, h HandlerFunc, ctx *Context) {
-	// group pre
-	if r.middlewares != nil {
-		for _, middlewareFunc := range r.middlewares {
-			h = middlewareFunc(h)
-		}
-	}
-	// router level
-	middlewareFuncs := r.middlewaresFuncMap[name][method]
-	if middlewareFuncs != nil {
-		for _, middlewareFunc := range middlewareFuncs {
-			h = middlewareFunc(h)
-		}
+// allMiddlewares returns the engine middlewares, then the ones of the parent
+// groups and then the ones of r.
+func (r *routerGroup) allMiddlewares() []MiddlewareFunc {
+	var inherited []MiddlewareFunc
+	if r.parent != nil {
+		inherited = r.parent.allMiddlewares()
+	} else {
+		inherited = r.router.engine.middles
 	}
-	h(ctx)
+	middlewares := make([]MiddlewareFunc, 0, len(inherited)+len(r.middlewares))
+	middlewares = append(middlewares, inherited...)
+	return append(middlewares, r.middlewares...)
 }
 
 func (r *routerGroup) handle(name string, method string, handleFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
@@ -67,7 +110,11 @@ func (r *routerGroup) handle(name string, method string, handleFunc HandlerFunc,
 	}
 	r.handlerFuncMap[name][method] = handleFunc
 	r.middlewaresFuncMap[name][method] = append(r.middlewaresFuncMap[name][method], middlewareFunc...)
-	r.treeNode.Put(name)
+	r.handlerMethodMap[method] = append(r.handlerMethodMap[method], name)
+	rt := &route{group: r, name: name, method: method}
+	rt.compile()
+	r.routes = append(r.routes, rt)
+	r.router.addRoute(method, joinPaths(r.prefix, name), rt)
 }
 
 func (r *routerGroup) Any(name string, handleFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
@@ -97,28 +144,164 @@ func (r *routerGroup) Patch(name string, handleFunc HandlerFunc, middlewareFunc
 	r.handle(name, http.MethodPatch, handleFunc, middlewareFunc...)
 }
 
+func (r *routerGroup) Head(name string, handleFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
+	r.handle(name, http.MethodHead, handleFunc, middlewareFunc...)
+}
+
 func (r *routerGroup) Options(name string, handleFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
 	r.handle(name, http.MethodOptions, handleFunc, middlewareFunc...)
 }
 
+type route struct {
+	group    *routerGroup
+	name     string
+	method   string
+	handlers HandlersChain
+}
+
+// compile stores the handler chain of the route. The route middlewares run
+// first, then the group middlewares and then the handler.
+func (rt *route) compile() {
+	g := rt.group
+	middlewares := append(g.allMiddlewares(), g.middlewaresFuncMap[rt.name][rt.method]...)
+	rt.handlers = combineHandlers(middlewares, g.handlerFuncMap[rt.name][rt.method])
+}
+
+type routeNode struct {
+	fullPath string
+	routes   map[string]*route
+}
+
+// match returns the route serving method, HEAD falls back to the GET route.
+func (n *routeNode) match(method string) *route {
+	if rt, ok := n.routes[ANY]; ok {
+		return rt
+	}
+	if rt, ok := n.routes[method]; ok {
+		return rt
+	}
+	if method == http.MethodHead {
+		if rt, ok := n.routes[http.MethodGet]; ok {
+			return rt
+		}
+	}
+	return nil
+}
+
+func (n *routeNode) allow(handleOptions bool) string {
+	methods := make([]string, 0, len(n.routes)+2)
+	for method := range n.routes {
+		methods = append(methods, method)
+	}
+	if _, ok := n.routes[http.MethodGet]; ok {
+		if _, ok := n.routes[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	if _, ok := n.routes[http.MethodOptions]; !ok && handleOptions {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
 type router struct {
 	routerGroups []*routerGroup
+	rootGroup    *routerGroup
 	engine       *Engine
+	tree         radix.Tree[*routeNode]
+	routeNodes   map[string]*routeNode
 }
 
 func (r *router) Group(name string) *routerGroup {
+	return r.newGroup(name, joinPaths("/", name), nil)
+}
+
+// root returns the group of the routes the engine registers itself.
+func (r *router) root() *routerGroup {
+	if r.rootGroup == nil {
+		r.rootGroup = r.Group("")
+	}
+	return r.rootGroup
+}
+
+func (r *router) newGroup(name, prefix string, parent *routerGroup) *routerGroup {
 	routerGroup := &routerGroup{
 		name:               name,
+		prefix:             prefix,
+		router:             r,
 		handlerFuncMap:     make(map[string]map[string]HandlerFunc),
 		middlewaresFuncMap: make(map[string]map[string][]MiddlewareFunc),
 		handlerMethodMap:   make(map[string][]string),
-		treeNode:           &treeNode{name: "/", children: make([]*treeNode, 0)},
+		parent:             parent,
 	}
-	routerGroup.Use(r.engine.middles...)
 	r.routerGroups = append(r.routerGroups, routerGroup)
 	return routerGroup
 }
 
+// compile rebuilds the handler chains of all routes, so middlewares added
+// after a route reach it.
+func (r *router) compile() {
+	for _, group := range r.routerGroups {
+		for _, rt := range group.routes {
+			rt.compile()
+		}
+	}
+}
+
+func (r *router) addRoute(method, fullPath string, rt *route) {
+	node, ok := r.routeNodes[fullPath]
+	if !ok {
+		node = &routeNode{fullPath: fullPath, routes: make(map[string]*route)}
+		if err := r.tree.Add(fullPath, node); err != nil {
+			panic(err)
+		}
+		if r.routeNodes == nil {
+			r.routeNodes = make(map[string]*routeNode)
+		}
+		r.routeNodes[fullPath] = node
+	}
+	if _, ok := node.routes[method]; ok {
+		panic("Duplicate routing [" + fullPath + "]")
+	}
+	node.routes[method] = rt
+}
+
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Handler     string
+	Middlewares []string
+}
+
+func (r *routerGroup) routesInfo() []RouteInfo {
+	var routes []RouteInfo
+	for method, names := range r.handlerMethodMap {
+		for _, name := range names {
+			var middlewares []string
+			for _, m := range r.allMiddlewares() {
+				middlewares = append(middlewares, nameOfFunction(m))
+			}
+			for _, m := range r.middlewaresFuncMap[name][method] {
+				middlewares = append(middlewares, nameOfFunction(m))
+			}
+			routes = append(routes, RouteInfo{
+				Method:      method,
+				Path:        joinPaths(r.prefix, name),
+				Handler:     nameOfFunction(r.handlerFuncMap[name][method]),
+				Middlewares: middlewares,
+			})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
 type ErrorHandler func(err error) (int, any)
 
 type Engine struct {
@@ -133,43 +316,129 @@ type Engine struct {
 	gatewayConfigs   []gateway.GWConfig
 	gatewayTreeNode  *gateway.TreeNode
 	gatewayConfigMap map[string]gateway.GWConfig
+	noRoute          HandlersChain
+	noMethod         HandlersChain
+	allNoRoute       HandlersChain
+	allNoMethod      HandlersChain
+	allOptions       HandlersChain
+	// HandleMethodNotAllowed answers 405 with an Allow header when the path
+	// matches but the method does not, otherwise such requests get a 404.
+	HandleMethodNotAllowed bool
+	// HandleOPTIONS answers OPTIONS requests on paths without an OPTIONS route.
+	HandleOPTIONS bool
+	// RedirectTrailingSlash redirects /foo/ to /foo, or the reverse, when only
+	// the other form is registered.
+	RedirectTrailingSlash bool
+	// RedirectCleanPath redirects paths with "..", "." or "//" elements to
+	// their cleaned form when that form is registered.
+	RedirectCleanPath bool
+	// RedirectCaseInsensitive redirects to a registered route that matches
+	// the path ignoring case, e.g. /USER/info to /user/info.
+	RedirectCaseInsensitive bool
+	// SecureJSONPrefix is written in front of arrays by Context.SecureJSON.
+	SecureJSONPrefix string
+	// MaxMultipartMemory is the part of a multipart form kept in memory,
+	// the rest of the files is stored on disk. 32MB when zero.
+	MaxMultipartMemory int64
+	// Upgrader is used by Context.Upgrade and routes added with WS.
+	Upgrader websocket.Upgrader
+	// RemoteIPHeaders are read in order by Context.ClientIP for requests
+	// from trusted proxies.
+	RemoteIPHeaders []string
+	trustedCIDRs    []*net.IPNet
+	serverOptions   []ServerOption
+	serverMu        sync.Mutex
+	servers         []*http.Server
+	inShutdown      bool
+	shutdownHooks   []func()
+	shutdownDone    chan struct{}
 }
 
 func New() *Engine {
 	engine := &Engine{
-		router:           router{},
-		gatewayTreeNode:  &gateway.TreeNode{Name: "/", Children: make([]*gateway.TreeNode, 0)},
-		gatewayConfigMap: make(map[string]gateway.GWConfig),
+		router:                 router{},
+		gatewayTreeNode:        &gateway.TreeNode{},
+		errorHandler:           defaultErrorHandler,
+		SecureJSONPrefix:       "while(1);",
+		RemoteIPHeaders:        defaultRemoteIPHeaders,
+		gatewayConfigMap:       make(map[string]gateway.GWConfig),
+		noRoute:                HandlersChain{notFoundHandler},
+		noMethod:               HandlersChain{notAllowedHandler},
+		HandleMethodNotAllowed: true,
+		HandleOPTIONS:          true,
+		RedirectTrailingSlash:  true,
 	}
+	engine.router.engine = engine
+	engine.rebuildChains()
 	engine.pool.New = func() any {
 		return engine.allocateContext()
 	}
 	return engine
 }
 
+func init() {
+	config.Validate = func(obj any) error {
+		return binding.Validator.ValidateStruct(obj)
+	}
+}
+
 func Default() *Engine {
 	engine := New()
 	engine.Logger = zormlog.Default()
-	logPath, ok := config.Conf.Log["path"]
-	if ok {
-		engine.Logger.SetLogPath(logPath.(string))
-	} else {
-		engine.Logger.SetLogPath("./log")
+	conf := config.Current().Zorm().Log
+	logPath := conf.Path
+	if logPath == "" {
+		logPath = "./log"
 	}
-	engine.Use(Logging, Recovery)
-	engine.router.engine = engine
+	engine.Logger.SetLogPath(logPath)
+	if conf.Level != "" {
+		engine.setLogLevel(conf.Level)
+	}
+	config.OnChange("log.level", func(old, new *config.Config) {
+		level := new.Zorm().Log.Level
+		if level == "" {
+			level = "debug"
+		}
+		engine.setLogLevel(level)
+	})
+	engine.Use(RequestID, Logging, Recovery)
 	return engine
 }
 
+func (e *Engine) setLogLevel(name string) {
+	level, err := zormlog.ParseLevel(name)
+	if err != nil {
+		e.Logger.Error(err)
+		return
+	}
+	e.Logger.SetLevel(level)
+}
+
+func (e *Engine) maxMultipartMemory() int64 {
+	if e.MaxMultipartMemory > 0 {
+		return e.MaxMultipartMemory
+	}
+	return defaultMaxMemory
+}
+
 func (e *Engine) allocateContext() any {
-	return &Context{engine: e}
+	ctx := &Context{engine: e, params: make(Params, 0, e.tree.MaxParams())}
+	ctx.writer.statusCode = &ctx.StatusCode
+	return ctx
 }
 
-func (e *Engine) SetGatewayConfig(configs []gateway.GWConfig) {
+// SetGatewayConfig registers the gateway routes, it returns an error for a
+// path conflicting with one registered before, the others are still added.
+func (e *Engine) SetGatewayConfig(configs []gateway.GWConfig) error {
+	var errs []error
 	for _, v := range configs {
-		e.gatewayTreeNode.Put(v.Path, v.Name)
+		if err := e.gatewayTreeNode.Add(v.Path, v.Name); err != nil {
+			errs = append(errs, fmt.Errorf("gateway %s: %w", v.Name, err))
+			continue
+		}
 		e.gatewayConfigMap[v.Name] = v
 	}
+	return errors.Join(errs...)
 }
 
 func (e *Engine) SetFuncMap(funcMap template.FuncMap) {
@@ -182,12 +451,11 @@ func (e *Engine) LoadTemplate(pattern string) {
 }
 
 func (e *Engine) LoadTemplateConfig() error {
-	pattern, ok := config.Conf.Template["pattern"]
-	if !ok {
-		// todo 抛异常 打日志
+	pattern := config.Current().Zorm().Template.Pattern
+	if pattern == "" {
 		return errors.New("template pattern config not found")
 	}
-	t := template.Must(template.New("").Funcs(e.funcMap).ParseGlob(pattern.(string)))
+	t := template.Must(template.New("").Funcs(e.funcMap).ParseGlob(pattern))
 	e.SetHTMLTemplate(t)
 	return nil
 }
@@ -198,9 +466,11 @@ func (e *Engine) SetHTMLTemplate(t *template.Template) {
 
 func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := e.pool.Get().(*Context)
-	ctx.W = w
+	ctx.writer.reset(w)
+	ctx.W = &ctx.writer
 	ctx.R = r
 	ctx.Logger = e.Logger
+	ctx.reset()
 	e.httpRequestHandler(ctx, w, r)
 	e.pool.Put(ctx)
 }
@@ -208,14 +478,30 @@ func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (e *Engine) httpRequestHandler(ctx *Context, w http.ResponseWriter, r *http.Request) {
 	if e.OpenGateway {
 		path := r.URL.Path
-		node := e.gatewayTreeNode.Get(path)
-		if node == nil {
+		gwNode := e.gatewayTreeNode.Get(path)
+		if gwNode == nil {
 			ctx.W.WriteHeader(http.StatusNotFound)
 			fmt.Fprintln(ctx.W, ctx.R.RequestURI+" not found")
 			return
 		}
-		gwConfig := e.gatewayConfigMap[node.GwName]
-		gwConfig.Header(ctx.R)
+		gwName := gwNode.GwName
+		gwConfig := e.gatewayConfigMap[gwName]
+		// the gateway is where a request enters, it starts the request ID
+		// when the client sent none
+		requestID := r.Header.Get(requestid.Header)
+		if !requestid.Valid(requestID) {
+			requestID = requestid.New()
+		}
+		spanCtx, span := tracing.Start(tracing.Extract(r.Context(), r.Header), "gateway "+gwName, tracing.SpanKindServer)
+		defer span.End()
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.RequestURI())
+		span.SetAttribute("http.request_id", requestID)
+		span.SetAttribute("net.peer.ip", ctx.ClientIP())
+		r = r.WithContext(spanCtx)
+		if gwConfig.Header != nil {
+			gwConfig.Header(ctx.R)
+		}
 		target, err := url.Parse(fmt.Sprintf("http://%s:%d%s", gwConfig.Host, gwConfig.Port, path))
 		if err != nil {
 			ctx.W.WriteHeader(http.StatusInternalServerError)
@@ -227,72 +513,179 @@ func (e *Engine) httpRequestHandler(ctx *Context, w http.ResponseWriter, r *http
 			req.URL.Host = target.Host
 			req.URL.Path = target.Path
 			req.URL.Scheme = target.Scheme
+			ctx.setForwardedHeaders(req)
+			req.Header.Set(requestid.Header, requestID)
+			tracing.Inject(req.Context(), req.Header)
 			if _, ok := req.Header["User-Agent"]; !ok {
 				req.Header.Set("User-Agent", "")
 			}
 		}
 		response := func(response *http.Response) error {
-			// todo
-			log.Println("响应修改")
+			response.Header.Set(requestid.Header, requestID)
+			span.SetAttribute("http.status_code", response.StatusCode)
+			if response.StatusCode >= http.StatusInternalServerError {
+				span.SetStatus(tracing.StatusError, response.Status)
+			}
 			return nil
 		}
 		handler := func(writer http.ResponseWriter, request *http.Request, err error) {
-			log.Println("错误处理")
+			span.RecordError(err)
+			span.SetAttribute("http.status_code", http.StatusBadGateway)
+			writer.WriteHeader(http.StatusBadGateway)
 		}
 		proxy := httputil.ReverseProxy{
 			Director:       director,
 			ModifyResponse: response,
 			ErrorHandler:   handler,
 		}
-		proxy.ServeHTTP(w, r)
+		proxy.ServeHTTP(ctx.W, r)
 		return
 	}
 	method := r.Method
-	for _, group := range e.routerGroups {
-		routerName := SubStringLast(r.URL.Path, "/"+group.name)
-		node := group.treeNode.Get(routerName)
-		if node != nil && node.isEnd {
-
-			handle, ok := group.handlerFuncMap[node.routerName][ANY]
-			if ok {
-				group.methodHandle(node.routerName, ANY, handle, ctx)
-				return
+	node, ok := e.tree.Find(r.URL.Path, &ctx.params)
+	if ok {
+		if rt := node.match(method); rt != nil {
+			ctx.fullPath = node.fullPath
+			ctx.handlers = rt.handlers
+			ctx.Next()
+			return
+		}
+		if method == http.MethodOptions && e.HandleOPTIONS {
+			w.Header().Set("Allow", node.allow(e.HandleOPTIONS))
+			ctx.handlers = e.allOptions
+			ctx.Next()
+			return
+		}
+		if e.HandleMethodNotAllowed {
+			w.Header().Set("Allow", node.allow(e.HandleOPTIONS))
+			ctx.handlers = e.allNoMethod
+			ctx.Next()
+			return
+		}
+	}
+	if method != http.MethodConnect && r.URL.Path != "/" {
+		if location, ok := e.redirectPath(ctx, r.URL.Path); ok {
+			e.redirect(ctx, location)
+			return
+		}
+	}
+	ctx.handlers = e.allNoRoute
+	ctx.Next()
+}
+
+// redirectPath looks for a registered path that differs from path only by
+// the enabled fix-ups.
+func (e *Engine) redirectPath(ctx *Context, path string) (string, bool) {
+	candidates := []string{path}
+	if e.RedirectCleanPath {
+		if cleaned := cleanPath(path); cleaned != path {
+			candidates = []string{cleaned}
+			if _, ok := e.tree.Find(cleaned, &ctx.params); ok {
+				ctx.params = ctx.params[:0]
+				return cleaned, true
 			}
-			handle, ok = group.handlerFuncMap[node.routerName][method]
-			if ok {
-				group.methodHandle(node.routerName, method, handle, ctx)
-				return
+		}
+	}
+	if e.RedirectTrailingSlash {
+		for _, candidate := range candidates {
+			tsr := candidate + "/"
+			if strings.HasSuffix(candidate, "/") {
+				tsr = candidate[:len(candidate)-1]
 			}
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			fmt.Fprintf(w, "%s %s not allowed \n", r.RequestURI, method)
-			return
+			if _, ok := e.tree.Find(tsr, &ctx.params); ok {
+				ctx.params = ctx.params[:0]
+				return tsr, true
+			}
+			candidates = append(candidates, tsr)
 		}
+	}
+	if e.RedirectCaseInsensitive {
+		for _, candidate := range candidates {
+			if fixed, ok := e.tree.FindCaseInsensitive(candidate); ok {
+				return fixed, true
+			}
+		}
+	}
+	return "", false
+}
 
+// redirect answers with 301 for GET requests and 308 for other methods, so
+// the method and body are kept.
+func (e *Engine) redirect(ctx *Context, location string) {
+	code := http.StatusPermanentRedirect
+	if ctx.R.Method == http.MethodGet {
+		code = http.StatusMovedPermanently
+	}
+	if ctx.R.URL.RawQuery != "" {
+		location += "?" + ctx.R.URL.RawQuery
 	}
-	w.WriteHeader(http.StatusNotFound)
-	fmt.Fprintf(w, "%s not found \n", r.RequestURI)
+	http.Redirect(ctx.W, ctx.R, location, code)
 }
 
-func (e *Engine) Run(addr string) {
-	http.Handle("/", e)
-	err := http.ListenAndServe(addr, nil)
-	if err != nil {
-		log.Fatal(err)
+func (e *Engine) rebuildChains() {
+	e.allNoRoute = combineHandlers(e.middles, e.noRoute...)
+	e.allNoMethod = combineHandlers(e.middles, e.noMethod...)
+	e.allOptions = combineHandlers(e.middles, optionsHandler)
+}
+
+// NoRoute replaces the handlers for requests that match no route. They run
+// after the engine middlewares and are expected to write the response.
+func (e *Engine) NoRoute(handlers ...HandlerFunc) {
+	e.noRoute = handlers
+	e.rebuildChains()
+}
+
+// NoMethod replaces the handlers for requests whose path matches but whose
+// method does not. The Allow header is already set when they run.
+func (e *Engine) NoMethod(handlers ...HandlerFunc) {
+	e.noMethod = handlers
+	e.rebuildChains()
+}
+
+func notFoundHandler(ctx *Context) {
+	ctx.String(http.StatusNotFound, "%s not found \n", ctx.R.RequestURI)
+}
+
+func notAllowedHandler(ctx *Context) {
+	ctx.String(http.StatusMethodNotAllowed, "%s %s not allowed \n", ctx.R.RequestURI, ctx.R.Method)
+}
+
+func optionsHandler(ctx *Context) {
+	ctx.W.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) Routes() []RouteInfo {
+	var routes []RouteInfo
+	for _, group := range e.routerGroups {
+		routes = append(routes, group.routesInfo()...)
 	}
+	return routes
 }
 
 func (e *Engine) Use(middles ...MiddlewareFunc) {
 	e.middles = append(e.middles, middles...)
+	e.rebuildChains()
+	e.router.compile()
 }
 
 func (e *Engine) RegisterErrorHandler(handler ErrorHandler) {
 	e.errorHandler = handler
 }
 
-func (e *Engine) RunTLS(addr, certFile, keyFile string) {
-	err := http.ListenAndServeTLS(addr, certFile, keyFile, e.Handler())
-	if err != nil {
-		log.Fatal(err)
+// defaultErrorHandler answers binding.ValidationErrors with 400 and the
+// failed fields, other errors are not exposed to the client.
+func defaultErrorHandler(err error) (int, any) {
+	var verrs binding.ValidationErrors
+	if errors.As(err, &verrs) {
+		return http.StatusBadRequest, map[string]any{
+			"code":   http.StatusBadRequest,
+			"msg":    "validation failed",
+			"errors": verrs,
+		}
+	}
+	return http.StatusInternalServerError, map[string]any{
+		"code": http.StatusInternalServerError,
+		"msg":  http.StatusText(http.StatusInternalServerError),
 	}
 }
 
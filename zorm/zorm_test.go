@@ -0,0 +1,454 @@
+package zorm
+
+import (
+	"errors"
+	"github.com/caixr9527/zorm/binding"
+	zormlog "github.com/caixr9527/zorm/log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testHandler(ctx *Context) {}
+
+func testMiddleware(next HandlerFunc) HandlerFunc {
+	return next
+}
+
+func TestEngineRoutes(t *testing.T) {
+	engine := New()
+	group := engine.Group("user")
+	group.Use(testMiddleware)
+	group.Get("/get/:id", testHandler)
+	group.Post("/add", testHandler, testMiddleware)
+
+	routes := engine.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("got %d routes, want 2", len(routes))
+	}
+	want := []RouteInfo{
+		{Method: "POST", Path: "/user/add", Handler: "github.com/caixr9527/zorm.testHandler"},
+		{Method: "GET", Path: "/user/get/:id", Handler: "github.com/caixr9527/zorm.testHandler"},
+	}
+	for i, route := range routes {
+		if route.Method != want[i].Method || route.Path != want[i].Path || route.Handler != want[i].Handler {
+			t.Errorf("route %d = %+v, want %+v", i, route, want[i])
+		}
+	}
+	if len(routes[0].Middlewares) != 2 || len(routes[1].Middlewares) != 1 {
+		t.Errorf("unexpected middlewares %v %v", routes[0].Middlewares, routes[1].Middlewares)
+	}
+}
+
+func TestRouteConflict(t *testing.T) {
+	engine := New()
+	group := engine.Group("a")
+	group.Get("/:id", testHandler)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected conflicting route to panic")
+		}
+	}()
+	group.Get("/:name", testHandler)
+}
+
+func TestNestedGroup(t *testing.T) {
+	engine := New()
+	var calls []string
+	trace := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx *Context) {
+				calls = append(calls, name)
+				next(ctx)
+			}
+		}
+	}
+	engine.Use(trace("engine"))
+	api := engine.Group("api")
+	api.Use(trace("api"))
+	v1 := api.Group("v1")
+	v1.Use(trace("v1"))
+	v1.Get("/user/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, ctx.Param("id"))
+	})
+	user := engine.Group("user")
+	user.Get("/info", testHandler)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user/7", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "7" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	if strings.Join(calls, ",") != "v1,api,engine" {
+		t.Errorf("middlewares ran as %v", calls)
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/user/info", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("group prefix is not anchored, got %d", w.Code)
+	}
+	if routes := engine.Routes(); len(routes) != 2 || routes[0].Path != "/api/v1/user/:id" {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+}
+
+func TestGroupInheritsLaterMiddlewares(t *testing.T) {
+	engine := New()
+	var calls []string
+	trace := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx *Context) {
+				calls = append(calls, name)
+				next(ctx)
+			}
+		}
+	}
+	api := engine.Group("api")
+	v1 := api.Group("v1")
+	v1.Get("/info", testHandler)
+	api.Use(trace("api"))
+	engine.Use(trace("engine"))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/info", nil))
+	if strings.Join(calls, ",") != "api,engine" {
+		t.Errorf("middlewares ran as %v", calls)
+	}
+	if routes := engine.Routes(); len(routes) != 1 || len(routes[0].Middlewares) != 2 {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+}
+
+func TestNoRouteAndNoMethod(t *testing.T) {
+	engine := New()
+	var middlewareCalls int
+	engine.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			middlewareCalls++
+			next(ctx)
+		}
+	})
+	engine.NoRoute(func(ctx *Context) {
+		ctx.JSON(http.StatusNotFound, map[string]string{"msg": "no route"})
+	})
+	engine.NoMethod(func(ctx *Context) {
+		ctx.JSON(http.StatusMethodNotAllowed, map[string]string{"msg": "no method"})
+	})
+	group := engine.Group("user")
+	group.Get("/info", testHandler)
+	group.Post("/info", testHandler)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/none", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"msg":"no route"}` {
+		t.Errorf("no route: got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/user/info", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Body.String() != `{"msg":"no method"}` {
+		t.Errorf("no method: got %d %q", w.Code, w.Body.String())
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("got Allow %q", allow)
+	}
+	if middlewareCalls != 2 {
+		t.Errorf("engine middleware ran %d times, want 2", middlewareCalls)
+	}
+}
+
+func TestHeadAndOptions(t *testing.T) {
+	engine := New()
+	group := engine.Group("user")
+	group.Get("/info", func(ctx *Context) {
+		ctx.String(http.StatusOK, "info")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/user/info", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("HEAD: got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/user/info", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("OPTIONS: got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	engine.HandleOPTIONS = false
+	engine.HandleMethodNotAllowed = false
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/user/info", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("OPTIONS disabled: got %d", w.Code)
+	}
+}
+
+func TestRedirectPath(t *testing.T) {
+	engine := New()
+	group := engine.Group("user")
+	group.Get("/hello", testHandler)
+	group.Post("/list/", testHandler)
+
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/user/hello/", http.StatusMovedPermanently, "/user/hello"},
+		{http.MethodGet, "/user/hello/?a=1", http.StatusMovedPermanently, "/user/hello?a=1"},
+		{http.MethodPost, "/user/list", http.StatusPermanentRedirect, "/user/list/"},
+		{http.MethodGet, "/user/../user/hello", http.StatusNotFound, ""},
+		{http.MethodGet, "/USER/Hello", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: got %d %q", tt.method, tt.path, w.Code, w.Header().Get("Location"))
+		}
+	}
+
+	engine.RedirectTrailingSlash = false
+	engine.RedirectCleanPath = true
+	engine.RedirectCaseInsensitive = true
+	tests = []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/user/hello/", http.StatusNotFound, ""},
+		{http.MethodGet, "/user//x/../hello", http.StatusMovedPermanently, "/user/hello"},
+		{http.MethodGet, "/USER/Hello", http.StatusMovedPermanently, "/user/hello"},
+		{http.MethodPost, "/User/LIST/", http.StatusPermanentRedirect, "/user/list/"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/", nil)
+		r.URL.Path = tt.path
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: got %d %q", tt.method, tt.path, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestHandlerChain(t *testing.T) {
+	engine := New()
+	var calls []string
+	group := engine.Group("user")
+	group.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			calls = append(calls, "before")
+			next(ctx)
+			calls = append(calls, "after")
+		}
+	})
+	group.Get("/info", func(ctx *Context) {
+		calls = append(calls, "handler")
+	})
+	auth := func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			if ctx.GetHeader("Authorization") == "" {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			next(ctx)
+		}
+	}
+	skip := func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			ctx.String(http.StatusForbidden, "forbidden")
+		}
+	}
+	group.Get("/secret", func(ctx *Context) {
+		calls = append(calls, "secret")
+	}, auth)
+	group.Get("/skip", func(ctx *Context) {
+		calls = append(calls, "skip")
+	}, skip)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/info", nil))
+	if strings.Join(calls, ",") != "before,handler,after" {
+		t.Errorf("chain ran as %v", calls)
+	}
+
+	calls = nil
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/secret", nil))
+	if w.Code != http.StatusUnauthorized || len(calls) != 0 {
+		t.Errorf("abort: got %d, chain ran as %v", w.Code, calls)
+	}
+
+	calls = nil
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/skip", nil))
+	if w.Code != http.StatusForbidden || len(calls) != 0 {
+		t.Errorf("middleware without next: got %d, chain ran as %v", w.Code, calls)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	engine := New()
+	var calls []string
+	wraps := 0
+	trace := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			wraps++
+			return func(ctx *Context) {
+				calls = append(calls, name)
+				next(ctx)
+			}
+		}
+	}
+	group := engine.Group("user")
+	group.Use(trace("group1"), trace("group2"))
+	group.Get("/info", testHandler, trace("route1"), trace("route2"))
+
+	for i := 0; i < 2; i++ {
+		calls = nil
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/info", nil))
+		if strings.Join(calls, ",") != "route2,route1,group2,group1" {
+			t.Errorf("middlewares ran as %v", calls)
+		}
+	}
+	if wraps != 8 {
+		t.Errorf("middlewares wrapped %d times, want 8", wraps)
+	}
+}
+
+func TestRecoveryAbortsChain(t *testing.T) {
+	engine := New()
+	engine.Logger = zormlog.Default()
+	engine.Logger.Outs = nil
+	var reached bool
+	group := engine.Group("user")
+	group.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			panic(errors.New("boom"))
+		}
+	})
+	group.Use(Recovery)
+	group.Get("/info", func(ctx *Context) {
+		reached = true
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/info", nil))
+	if w.Code != http.StatusInternalServerError || reached {
+		t.Errorf("got %d, handler reached %v", w.Code, reached)
+	}
+}
+
+func TestContextBind(t *testing.T) {
+	type user struct {
+		ID   int    `uri:"id"`
+		Name string `form:"name"`
+	}
+	engine := New()
+	engine.Group("user").Post("/:id", func(ctx *Context) {
+		var u user
+		if err := ctx.BindUri(&u); err != nil {
+			return
+		}
+		if err := ctx.Bind(&u); err != nil {
+			return
+		}
+		ctx.String(http.StatusOK, "%d %s", u.ID, u.Name)
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/user/7", strings.NewReader("name=zorm"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	engine.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "7 zorm" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/user/x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid uri: got %d", w.Code)
+	}
+}
+
+func TestBindValidationError(t *testing.T) {
+	type page struct {
+		Size int `form:"size" validate:"lte=100"`
+	}
+	engine := New()
+	engine.Group("list").Get("/", func(ctx *Context) {
+		var p page
+		if err := ctx.BindQuery(&p); err != nil {
+			return
+		}
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/list/?size=500", nil)
+	r.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+	engine.ServeHTTP(w, r)
+	want := `{"code":400,"errors":[{"field":"size","rule":"lte","param":"100","message":"size必须小于或等于100"}],"msg":"validation failed"}`
+	if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBindJsonFlags(t *testing.T) {
+	type user struct {
+		Name string `json:"name" required:"true"`
+	}
+	engine := New()
+	group := engine.Group("user")
+	group.Post("/strict", func(ctx *Context) {
+		ctx.DisallowUnknownFields = true
+		ctx.IsValidate = true
+		if err := ctx.BindJson(&user{}, binding.WithMaxBodySize(64)); err != nil {
+			return
+		}
+		ctx.String(http.StatusOK, "ok")
+	})
+	group.Post("/override", func(ctx *Context) {
+		ctx.DisallowUnknownFields = true
+		ctx.IsValidate = true
+		if err := ctx.BindJson(&user{}, binding.WithDisallowUnknownFields(false), binding.WithValidate(false)); err != nil {
+			return
+		}
+		ctx.String(http.StatusOK, "ok")
+	})
+	group.Post("/lenient", func(ctx *Context) {
+		if err := ctx.Bind(&user{}); err != nil {
+			return
+		}
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		path, body string
+		code       int
+	}{
+		{"/user/strict", `{"name":"a"}`, http.StatusOK},
+		{"/user/strict", `{"name":""}`, http.StatusOK},
+		{"/user/strict", `{"name":"a","age":1}`, http.StatusBadRequest},
+		{"/user/strict", `{}`, http.StatusBadRequest},
+		{"/user/strict", `{"name":"` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge},
+		{"/user/override", `{"age":1}`, http.StatusOK},
+		{"/user/lenient", `{"age":1}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", binding.MIMEJSON)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("%s %s: got %d, want %d", tt.path, tt.body, w.Code, tt.code)
+		}
+	}
+}
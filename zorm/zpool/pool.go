@@ -46,11 +46,14 @@ func NewPool(cap int32) (*Pool, error) {
 }
 
 func New() (*Pool, error) {
-	c, ok := config.Conf.Pool["cap"]
-	if !ok {
-		c = 10
+	conf := config.Current().Zorm().Pool
+	if conf.Cap <= 0 {
+		conf.Cap = 10
 	}
-	return NewPool(c.(int32))
+	if conf.Expire <= 0 {
+		conf.Expire = DefaultExpire
+	}
+	return NewPoolWithExpire(conf.Cap, conf.Expire)
 }
 
 func NewPoolWithExpire(cap int32, expire int32) (*Pool, error) {